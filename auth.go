@@ -0,0 +1,97 @@
+package httpc
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+)
+
+type APIKeyIn int
+
+const (
+	APIKeyInHeader APIKeyIn = iota
+	APIKeyInQuery
+)
+
+func WithBasicAuth(username, password string) RequestOption {
+	return func(o *RequestOptions) error {
+		cred := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		o.Header.Set("Authorization", "Basic "+cred)
+		return nil
+	}
+}
+
+func WithBearerToken(token string) RequestOption {
+	return func(o *RequestOptions) error {
+		if token == "" {
+			return fmt.Errorf("missing bearer token")
+		}
+		o.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+}
+
+func WithAPIKey(in APIKeyIn, name, value string) RequestOption {
+	return func(o *RequestOptions) error {
+		if name == "" {
+			return fmt.Errorf("missing api key name")
+		}
+		switch in {
+		case APIKeyInHeader:
+			o.Header.Set(name, value)
+		case APIKeyInQuery:
+			o.Queries.Set(name, value)
+		default:
+			return fmt.Errorf("unknown api key location: %v", in)
+		}
+		return nil
+	}
+}
+
+type Token struct {
+	Type   string
+	Value  string
+	Expiry time.Time
+}
+
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+type TokenSourceFunc func(ctx context.Context) (*Token, error)
+
+func (f TokenSourceFunc) Token(ctx context.Context) (*Token, error) {
+	return f(ctx)
+}
+
+func StaticTokenSource(token string) TokenSource {
+	return TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+		return &Token{Type: "Bearer", Value: token}, nil
+	})
+}
+
+func WithTokenSource(ts TokenSource) RequestOption {
+	return func(o *RequestOptions) error {
+		if ts == nil {
+			return fmt.Errorf("nil token source")
+		}
+		ctx := o.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		token, err := ts.Token(ctx)
+		if err != nil {
+			return fmt.Errorf("fetch token: %w", err)
+		}
+		if token == nil {
+			return fmt.Errorf("fetch token: nil token")
+		}
+		typ := token.Type
+		if typ == "" {
+			typ = "Bearer"
+		}
+		o.Header.Set("Authorization", typ+" "+token.Value)
+		return nil
+	}
+}
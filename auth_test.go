@@ -0,0 +1,85 @@
+package httpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestAuthOptions(t *testing.T) {
+	rawurl := "http://web.example"
+
+	t.Run("WithBasicAuth", func(t *testing.T) {
+		req, err := NewRequest(context.Background(), http.MethodGet, rawurl, WithBasicAuth("john", "secret"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		user, pass, ok := req.BasicAuth()
+		if !ok || user != "john" || pass != "secret" {
+			t.Errorf("unexpected basic auth. expected: john:secret, got: %v:%v", user, pass)
+		}
+	})
+	t.Run("WithBearerToken", func(t *testing.T) {
+		expected := "Bearer xxxxxxxx"
+		req, err := NewRequest(context.Background(), http.MethodGet, rawurl, WithBearerToken("xxxxxxxx"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := req.Header.Get("Authorization"); got != expected {
+			t.Errorf("unexpected authorization. expected: %v, got: %v", expected, got)
+		}
+	})
+	t.Run("WithAPIKey", func(t *testing.T) {
+		req, err := NewRequest(context.Background(), http.MethodGet, rawurl,
+			WithAPIKey(APIKeyInHeader, "X-API-Key", "header-key"),
+			WithAPIKey(APIKeyInQuery, "api_key", "query-key"),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := req.Header.Get("X-API-Key"); got != "header-key" {
+			t.Errorf("unexpected X-API-Key header. expected: header-key, got: %v", got)
+		}
+		if got := req.URL.Query().Get("api_key"); got != "query-key" {
+			t.Errorf("unexpected api_key param. expected: query-key, got: %v", got)
+		}
+	})
+	t.Run("WithTokenSource", func(t *testing.T) {
+		type ctxKey struct{}
+		n := 0
+		ts := TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+			if ctx.Value(ctxKey{}) == nil {
+				return nil, errors.New("unexpected context")
+			}
+			n++
+			return &Token{Value: string(rune('0' + n))}, nil
+		})
+		rb, err := NewRequestBuilder(rawurl, nil, WithDefaultOptions(WithTokenSource(ts)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.WithValue(context.Background(), ctxKey{}, true)
+		for _, expected := range []string{"Bearer 1", "Bearer 2"} {
+			req, err := rb.NewRequest(ctx, http.MethodGet, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := req.Header.Get("Authorization"); got != expected {
+				t.Errorf("unexpected authorization. expected: %v, got: %v", expected, got)
+			}
+		}
+		t.Run("NilToken", func(t *testing.T) {
+			ts := TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+				return nil, nil
+			})
+			req, err := NewRequest(context.Background(), http.MethodGet, rawurl, WithTokenSource(ts))
+			if err == nil {
+				t.Errorf("accept nil token")
+			}
+			if req != nil {
+				t.Errorf("return invalid request")
+			}
+		})
+	})
+}
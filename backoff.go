@@ -1,7 +1,10 @@
 package httpc
 
 import (
+	"math"
 	"math/rand"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -9,22 +12,81 @@ type BackoffStrategy interface {
 	Backoff(attempt uint) time.Duration
 }
 
-type exponentialBackoff struct{}
+type randSource interface {
+	Int63n(n int64) int64
+}
+
+type backoffOptions struct {
+	rand randSource
+}
+
+type BackoffOption func(*backoffOptions)
+
+func newBackoffOptions(opts ...BackoffOption) *backoffOptions {
+	options := &backoffOptions{rand: pooledRand{}}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+func WithRandSource(src rand.Source) BackoffOption {
+	return func(o *backoffOptions) {
+		o.rand = &lockedRand{r: rand.New(src)}
+	}
+}
+
+func WithSeed(seed int64) BackoffOption {
+	return WithRandSource(rand.NewSource(seed))
+}
+
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func (l *lockedRand) Int63n(n int64) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Int63n(n)
+}
+
+var randSeed = time.Now().UnixNano()
+
+var randPool = sync.Pool{
+	New: func() interface{} {
+		return rand.New(rand.NewSource(atomic.AddInt64(&randSeed, 1)))
+	},
+}
+
+type pooledRand struct{}
+
+func (pooledRand) Int63n(n int64) int64 {
+	r := randPool.Get().(*rand.Rand)
+	v := r.Int63n(n)
+	randPool.Put(r)
+	return v
+}
+
+type exponentialBackoff struct {
+	rand randSource
+}
 
 func ExponentialBackoff() BackoffStrategy {
-	return &exponentialBackoff{}
+	return &exponentialBackoff{newBackoffOptions().rand}
 }
 
-func (*exponentialBackoff) Backoff(attempt uint) time.Duration {
-	return time.Duration(rand.Int63n((1 << attempt) * int64(time.Second)))
+func (b *exponentialBackoff) Backoff(attempt uint) time.Duration {
+	return time.Duration(b.rand.Int63n((1 << attempt) * int64(time.Second)))
 }
 
 type truncatedExponentialBackoff struct {
 	maxAttempt uint
+	rand       randSource
 }
 
 func TruncatedExponentialBackoff(maxAttempt uint) BackoffStrategy {
-	return &truncatedExponentialBackoff{maxAttempt}
+	return &truncatedExponentialBackoff{maxAttempt, newBackoffOptions().rand}
 }
 
 func (b *truncatedExponentialBackoff) Backoff(attempt uint) time.Duration {
@@ -32,7 +94,7 @@ func (b *truncatedExponentialBackoff) Backoff(attempt uint) time.Duration {
 	if n > b.maxAttempt {
 		n = b.maxAttempt
 	}
-	return time.Duration(rand.Int63n((1 << n) * int64(time.Second)))
+	return time.Duration(b.rand.Int63n((1 << n) * int64(time.Second)))
 }
 
 type constantBackoff struct {
@@ -46,3 +108,140 @@ func ConstantBackoff(duration time.Duration) BackoffStrategy {
 func (b *constantBackoff) Backoff(attempt uint) time.Duration {
 	return b.duration
 }
+
+type Jitter int
+
+const (
+	NoJitter Jitter = iota
+	FullJitter
+	EqualJitter
+	DecorrelatedJitter
+)
+
+type BackoffConfig struct {
+	Base       time.Duration
+	Multiplier float64
+	Min        time.Duration
+	Max        time.Duration
+	Jitter     Jitter
+}
+
+var DefaultBackoffBase = time.Second
+var DefaultBackoffMultiplier = 2.0
+
+const decorrelatedJitterFactor = 3
+
+type backoffSession interface {
+	session() BackoffStrategy
+}
+
+func newBackoffSession(strategy BackoffStrategy) BackoffStrategy {
+	if s, ok := strategy.(backoffSession); ok {
+		return s.session()
+	}
+	return strategy
+}
+
+type configuredBackoff struct {
+	config   BackoffConfig
+	schedule func(attempt uint) float64
+	rand     randSource
+
+	mu   sync.Mutex
+	prev time.Duration
+}
+
+func newConfiguredBackoff(config BackoffConfig, schedule func(attempt uint) float64, opts []BackoffOption) *configuredBackoff {
+	if config.Base <= 0 {
+		config.Base = DefaultBackoffBase
+	}
+	return &configuredBackoff{
+		config:   config,
+		schedule: schedule,
+		rand:     newBackoffOptions(opts...).rand,
+	}
+}
+
+func (b *configuredBackoff) session() BackoffStrategy {
+	return &configuredBackoff{config: b.config, schedule: b.schedule, rand: b.rand}
+}
+
+func (b *configuredBackoff) randInt63n(n int64) int64 {
+	if n <= 0 {
+		return 0
+	}
+	return b.rand.Int63n(n)
+}
+
+func (b *configuredBackoff) Backoff(attempt uint) time.Duration {
+	c := b.config
+	d := c.cap(c.Base, b.schedule(attempt))
+	switch c.Jitter {
+	case FullJitter:
+		d = time.Duration(b.randInt63n(int64(d)))
+	case EqualJitter:
+		d = d/2 + time.Duration(b.randInt63n(int64(d-d/2)))
+	case DecorrelatedJitter:
+		// The previous delay is shared by every caller of this instance; retry uses a session per call.
+		b.mu.Lock()
+		prev := b.prev
+		if prev < c.Base {
+			prev = c.Base
+		}
+		upper := c.cap(prev, decorrelatedJitterFactor)
+		d = c.Base + time.Duration(b.randInt63n(int64(upper-c.Base)))
+		b.prev = d
+		b.mu.Unlock()
+	}
+	if d < c.Min {
+		d = c.Min
+	}
+	if c.Max > 0 && d > c.Max {
+		d = c.Max
+	}
+	return d
+}
+
+func (c BackoffConfig) multiplier() float64 {
+	if c.Multiplier <= 0 {
+		return DefaultBackoffMultiplier
+	}
+	return c.Multiplier
+}
+
+func (c BackoffConfig) cap(d time.Duration, factor float64) time.Duration {
+	f := float64(d) * factor
+	if c.Max > 0 && f > float64(c.Max) {
+		return c.Max
+	}
+	if f >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(f)
+}
+
+func ExponentialBackoffWithConfig(config BackoffConfig, opts ...BackoffOption) BackoffStrategy {
+	m := config.multiplier()
+	return newConfiguredBackoff(config, func(attempt uint) float64 {
+		if attempt == 0 {
+			return 1
+		}
+		return math.Pow(m, float64(attempt-1))
+	}, opts)
+}
+
+func FibonacciBackoffWithConfig(config BackoffConfig, opts ...BackoffOption) BackoffStrategy {
+	return newConfiguredBackoff(config, func(attempt uint) float64 {
+		a, b := 0.0, 1.0
+		for i := uint(0); i < attempt && !math.IsInf(b, 1); i++ {
+			a, b = b, a+b
+		}
+		return a
+	}, opts)
+}
+
+func LinearBackoffWithConfig(config BackoffConfig, opts ...BackoffOption) BackoffStrategy {
+	return newConfiguredBackoff(config, func(attempt uint) float64 {
+		return float64(attempt)
+	}, opts)
+}
@@ -0,0 +1,166 @@
+package httpc
+
+import (
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func backoffSequence(strategy BackoffStrategy, n uint) []time.Duration {
+	s := newBackoffSession(strategy)
+	var ds []time.Duration
+	for attempt := uint(1); attempt <= n; attempt++ {
+		ds = append(ds, s.Backoff(attempt))
+	}
+	return ds
+}
+
+func TestConfiguredBackoff(t *testing.T) {
+	ms := time.Millisecond
+
+	t.Run("NoJitter", func(t *testing.T) {
+		ts := []struct {
+			Name     string
+			Strategy BackoffStrategy
+			Expected []time.Duration
+		}{
+			{
+				Name:     "Exponential",
+				Strategy: ExponentialBackoffWithConfig(BackoffConfig{Base: 50 * ms, Max: time.Second}),
+				Expected: []time.Duration{50 * ms, 100 * ms, 200 * ms, 400 * ms, 800 * ms, time.Second, time.Second},
+			},
+			{
+				Name:     "ExponentialMultiplier",
+				Strategy: ExponentialBackoffWithConfig(BackoffConfig{Base: 10 * ms, Multiplier: 3}),
+				Expected: []time.Duration{10 * ms, 30 * ms, 90 * ms, 270 * ms},
+			},
+			{
+				Name:     "Fibonacci",
+				Strategy: FibonacciBackoffWithConfig(BackoffConfig{Base: 10 * ms, Max: 60 * ms}),
+				Expected: []time.Duration{10 * ms, 10 * ms, 20 * ms, 30 * ms, 50 * ms, 60 * ms},
+			},
+			{
+				Name:     "Linear",
+				Strategy: LinearBackoffWithConfig(BackoffConfig{Base: 10 * ms, Min: 15 * ms}),
+				Expected: []time.Duration{15 * ms, 20 * ms, 30 * ms, 40 * ms},
+			},
+		}
+		for _, tc := range ts {
+			if got := backoffSequence(tc.Strategy, uint(len(tc.Expected))); !reflect.DeepEqual(got, tc.Expected) {
+				t.Errorf("%v: unexpected delays. expected: %v, got: %v", tc.Name, tc.Expected, got)
+			}
+		}
+	})
+
+	t.Run("Jitter", func(t *testing.T) {
+		config := BackoffConfig{Base: 50 * ms, Max: time.Second}
+		for _, jitter := range []Jitter{FullJitter, EqualJitter, DecorrelatedJitter} {
+			config.Jitter = jitter
+			strategy := ExponentialBackoffWithConfig(config)
+			for i := 0; i < 100; i++ {
+				for attempt, d := range backoffSequence(strategy, 10) {
+					upper := config.Base << uint(attempt)
+					if upper > config.Max || jitter == DecorrelatedJitter {
+						upper = config.Max
+					}
+					lower := time.Duration(0)
+					switch jitter {
+					case EqualJitter:
+						lower = upper / 2
+					case DecorrelatedJitter:
+						lower = config.Base
+					}
+					if d < lower || d > upper {
+						t.Fatalf("jitter %v: delay out of range. expected: [%v, %v], got: %v", jitter, lower, upper, d)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("ZeroBase", func(t *testing.T) {
+		for _, jitter := range []Jitter{NoJitter, DecorrelatedJitter} {
+			strategy := ExponentialBackoffWithConfig(BackoffConfig{Jitter: jitter})
+			for _, d := range backoffSequence(strategy, 5) {
+				if d < DefaultBackoffBase {
+					t.Fatalf("jitter %v: unexpected delay: %v", jitter, d)
+				}
+			}
+		}
+	})
+	t.Run("Concurrent", func(t *testing.T) {
+		strategy := ExponentialBackoffWithConfig(BackoffConfig{Base: ms, Max: time.Second, Jitter: DecorrelatedJitter})
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for attempt := uint(1); attempt <= 10; attempt++ {
+					if d := strategy.Backoff(attempt); d < ms || d > time.Second {
+						t.Errorf("delay out of range: %v", d)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	})
+	t.Run("Overflow", func(t *testing.T) {
+		strategy := ExponentialBackoffWithConfig(BackoffConfig{Base: time.Second})
+		if got := strategy.Backoff(200); got <= 0 {
+			t.Errorf("unexpected delay: %v", got)
+		}
+	})
+}
+
+func TestBackoffSeed(t *testing.T) {
+	t.Run("ExponentialFullJitter", func(t *testing.T) {
+		r := rand.New(rand.NewSource(42))
+		var expected []time.Duration
+		for attempt := uint(1); attempt <= 5; attempt++ {
+			expected = append(expected, time.Duration(r.Int63n((1<<attempt)*int64(time.Second))))
+		}
+		if got := backoffSequence(ExponentialBackoffWithConfig(BackoffConfig{Base: 2 * time.Second, Jitter: FullJitter}, WithSeed(42)), 5); !reflect.DeepEqual(got, expected) {
+			t.Errorf("unexpected delays. expected: %v, got: %v", expected, got)
+		}
+	})
+	t.Run("TruncatedFullJitter", func(t *testing.T) {
+		r := rand.New(rand.NewSource(7))
+		var expected []time.Duration
+		for attempt := uint(1); attempt <= 5; attempt++ {
+			n := attempt
+			if n > 3 {
+				n = 3
+			}
+			expected = append(expected, time.Duration(r.Int63n((1<<n)*int64(time.Second))))
+		}
+		if got := backoffSequence(ExponentialBackoffWithConfig(BackoffConfig{Base: 2 * time.Second, Max: 8 * time.Second, Jitter: FullJitter}, WithSeed(7)), 5); !reflect.DeepEqual(got, expected) {
+			t.Errorf("unexpected delays. expected: %v, got: %v", expected, got)
+		}
+	})
+	t.Run("ConfiguredBackoff", func(t *testing.T) {
+		ms := time.Millisecond
+		ts := []struct {
+			Name     string
+			Strategy BackoffStrategy
+			Expected []time.Duration
+		}{
+			{
+				Name:     "DecorrelatedJitter",
+				Strategy: ExponentialBackoffWithConfig(BackoffConfig{Base: ms, Jitter: DecorrelatedJitter}, WithSeed(7)),
+				Expected: []time.Duration{2043955, 1376444, 3706714, 10084575, 12469231, 7255659},
+			},
+			{
+				Name:     "EqualJitter",
+				Strategy: FibonacciBackoffWithConfig(BackoffConfig{Base: ms, Jitter: EqualJitter}, WithSeed(7)),
+				Expected: []time.Duration{543955, 531224, 1473942, 2057379, 2786506, 4117713},
+			},
+		}
+		for _, tc := range ts {
+			if got := backoffSequence(tc.Strategy, uint(len(tc.Expected))); !reflect.DeepEqual(got, tc.Expected) {
+				t.Errorf("%v: unexpected delays. expected: %v, got: %v", tc.Name, tc.Expected, got)
+			}
+		}
+	})
+}
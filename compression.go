@@ -0,0 +1,146 @@
+package httpc
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+type Codec interface {
+	Encoding() string
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+type gzipCodec struct {
+	level int
+}
+
+func GzipCodec(level int) Codec {
+	return &gzipCodec{level}
+}
+
+func (*gzipCodec) Encoding() string {
+	return "gzip"
+}
+
+func (c *gzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(w, c.level)
+}
+
+func (*gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+type deflateCodec struct {
+	level int
+}
+
+func DeflateCodec(level int) Codec {
+	return &deflateCodec{level}
+}
+
+func (*deflateCodec) Encoding() string {
+	return "deflate"
+}
+
+func (c *deflateCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zlib.NewWriterLevel(w, c.level)
+}
+
+func (*deflateCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return zlib.NewReader(r)
+}
+
+var codecs = struct {
+	sync.RWMutex
+	m     map[string]Codec
+	order []string
+}{
+	m: map[string]Codec{},
+}
+
+func RegisterCodec(codec Codec) {
+	codecs.Lock()
+	defer codecs.Unlock()
+	enc := strings.ToLower(codec.Encoding())
+	if _, ok := codecs.m[enc]; !ok {
+		codecs.order = append(codecs.order, enc)
+	}
+	codecs.m[enc] = codec
+}
+
+func lookupCodec(encoding string) (Codec, bool) {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	c, ok := codecs.m[strings.ToLower(strings.TrimSpace(encoding))]
+	return c, ok
+}
+
+func acceptEncoding() string {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	return strings.Join(codecs.order, ", ")
+}
+
+func init() {
+	RegisterCodec(GzipCodec(gzip.DefaultCompression))
+	RegisterCodec(DeflateCodec(zlib.DefaultCompression))
+}
+
+func WithCompression(codec Codec) RequestOption {
+	return func(o *RequestOptions) error {
+		if codec == nil {
+			return fmt.Errorf("nil codec")
+		}
+		o.Compression = codec
+		return nil
+	}
+}
+
+type compressedBody struct {
+	*streamBody
+	codec  Codec
+	src    io.Reader
+	rewind func() (io.ReadCloser, error)
+}
+
+func newCompressedBody(codec Codec, src io.Reader) (*compressedBody, error) {
+	rewind, err := rewinder(src)
+	if err != nil {
+		return nil, fmt.Errorf("prepare compressed body: %w", err)
+	}
+	b := &compressedBody{codec: codec, src: src, rewind: rewind}
+	b.streamBody = newStreamBody(b.writeTo)
+	return b, nil
+}
+
+func (b *compressedBody) writeTo(w io.Writer) error {
+	cw, err := b.codec.NewWriter(w)
+	if err == nil {
+		if _, err = io.Copy(cw, b.src); err == nil {
+			return cw.Close()
+		}
+		cw.Close()
+	}
+	if c, ok := b.src.(io.Closer); ok {
+		c.Close()
+	}
+	return err
+}
+
+func (b *compressedBody) getBody() (io.ReadCloser, error) {
+	if b.rewind == nil {
+		return nil, ErrBodyNotRewindable
+	}
+	src, err := b.rewind()
+	if err != nil {
+		return nil, err
+	}
+	nb := &compressedBody{codec: b.codec, src: src, rewind: b.rewind}
+	nb.streamBody = newStreamBody(nb.writeTo)
+	return nb, nil
+}
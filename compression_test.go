@@ -0,0 +1,97 @@
+package httpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestWithCompression(t *testing.T) {
+	rawurl := "http://web.example"
+	expectedJSON := `{"text":"hello from test bot"}` + "\n"
+	data := map[string]string{"text": "hello from test bot"}
+
+	ts := []struct {
+		Codec    Codec
+		Encoding string
+	}{
+		{GzipCodec(gzip.BestSpeed), "gzip"},
+		{DeflateCodec(zlib.BestCompression), "deflate"},
+	}
+	for _, tc := range ts {
+		for _, enforce := range []bool{false, true} {
+			opts := []RequestOption{WithJSON(data), WithCompression(tc.Codec)}
+			if enforce {
+				opts = append(opts, EnforceContentLength)
+			}
+			req, err := NewRequest(context.Background(), http.MethodPost, rawurl, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := req.Header.Get("Content-Encoding"); got != tc.Encoding {
+				t.Errorf("unexpected content-encoding. expected: %v, got: %v", tc.Encoding, got)
+			}
+			for i := 0; i < 2; i++ {
+				body := req.Body
+				if i > 0 {
+					body, err = req.GetBody()
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+				b, err := ioutil.ReadAll(body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if enforce && req.ContentLength != int64(len(b)) {
+					t.Errorf("unexpected ContentLength. expected: %v, got: %v", len(b), req.ContentLength)
+				}
+				r, err := tc.Codec.NewReader(bytes.NewReader(b))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got, err := readAllString(r); got != expectedJSON {
+					t.Errorf("unexpected decompressed body. expected: %v, got: %v", expectedJSON, got)
+				} else if err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+
+	t.Run("DefaultOption", func(t *testing.T) {
+		rb, err := NewRequestBuilder(rawurl, nil, WithDefaultOptions(WithCompression(GzipCodec(gzip.BestSpeed))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := rb.NewRequest(context.Background(), http.MethodPost, "", WithJSON(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := req.Header.Get("Content-Encoding"); got != "gzip" {
+			t.Errorf("unexpected content-encoding. expected: gzip, got: %v", got)
+		}
+		r, err := GzipCodec(gzip.BestSpeed).NewReader(req.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := readAllString(r); got != expectedJSON {
+			t.Errorf("unexpected decompressed body. expected: %v, got: %v", expectedJSON, got)
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("NilCodec", func(t *testing.T) {
+		req, err := NewRequest(context.Background(), http.MethodPost, rawurl, WithJSON(data), WithCompression(nil))
+		if err == nil {
+			t.Errorf("accept nil codec")
+		}
+		if req != nil {
+			t.Errorf("return invalid request")
+		}
+	})
+}
@@ -0,0 +1,151 @@
+package httpc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var DefaultMaxDecompressedSize int64 = 256 << 20
+
+var ErrDecompressedTooLarge = errors.New("decompressed response body too large")
+
+type decompressTransport struct {
+	maxSize   int64
+	transport http.RoundTripper
+}
+
+func DecompressTransport(transport http.RoundTripper, maxSize int64) http.RoundTripper {
+	return &decompressTransport{maxSize: maxSize, transport: transport}
+}
+
+func (t *decompressTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt := t.transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if req.Header.Get("Accept-Encoding") != "" || req.Header.Get("Range") != "" {
+		return rt.RoundTrip(req)
+	}
+	r := req.Clone(req.Context())
+	r.Header.Set("Accept-Encoding", acceptEncoding())
+	resp, err := rt.RoundTrip(r)
+	if err != nil {
+		return resp, err
+	}
+	ce := resp.Header.Get("Content-Encoding")
+	if ce == "" || resp.Body == nil || resp.Body == http.NoBody {
+		return resp, nil
+	}
+	encodings := strings.Split(ce, ",")
+	decoders := make([]Codec, 0, len(encodings))
+	for i := len(encodings) - 1; i >= 0; i-- {
+		if strings.EqualFold(strings.TrimSpace(encodings[i]), "identity") {
+			continue
+		}
+		c, ok := lookupCodec(encodings[i])
+		if !ok {
+			return resp, nil
+		}
+		decoders = append(decoders, c)
+	}
+	maxSize := t.maxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxDecompressedSize
+	}
+	resp.Body = &decompressedBody{body: resp.Body, codecs: decoders, maxSize: maxSize}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+type decompressedBody struct {
+	body    io.ReadCloser
+	codecs  []Codec
+	maxSize int64
+
+	r       io.Reader
+	read    int64
+	closers []io.Closer
+	err     error
+}
+
+func (b *decompressedBody) init() error {
+	var r io.Reader = b.body
+	for _, c := range b.codecs {
+		dr, err := c.NewReader(r)
+		if err == io.EOF {
+			return err
+		}
+		if err != nil {
+			return fmt.Errorf("decode %v: %w", c.Encoding(), err)
+		}
+		b.closers = append(b.closers, dr)
+		r = dr
+	}
+	b.r = io.LimitReader(r, b.maxSize+1)
+	return nil
+}
+
+func (b *decompressedBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if b.r == nil {
+		if err := b.init(); err != nil {
+			b.err = err
+			return 0, err
+		}
+	}
+	n, err := b.r.Read(p)
+	b.read += int64(n)
+	if b.read > b.maxSize {
+		b.err = ErrDecompressedTooLarge
+		return n - int(b.read-b.maxSize), b.err
+	}
+	return n, err
+}
+
+func (b *decompressedBody) Close() error {
+	for i := len(b.closers) - 1; i >= 0; i-- {
+		b.closers[i].Close()
+	}
+	return b.body.Close()
+}
+
+func InjectDecompressTransport(client *http.Client, maxSize int64) error {
+	if client == nil {
+		return fmt.Errorf("missing client")
+	}
+	if dt, ok := client.Transport.(*debugTransport); ok {
+		if _, ok := dt.transport.(*decompressTransport); !ok {
+			dt.transport = &decompressTransport{maxSize: maxSize, transport: dt.transport}
+		}
+		return nil
+	}
+	if _, ok := client.Transport.(*decompressTransport); ok {
+		return nil
+	}
+	client.Transport = &decompressTransport{maxSize: maxSize, transport: client.Transport}
+	return nil
+}
+
+func RemoveDecompressTransport(client *http.Client) error {
+	if client == nil {
+		return fmt.Errorf("missing client")
+	}
+	if dt, ok := client.Transport.(*debugTransport); ok {
+		if t, ok := dt.transport.(*decompressTransport); ok {
+			dt.transport = t.transport
+		}
+		return nil
+	}
+	if t, ok := client.Transport.(*decompressTransport); ok {
+		client.Transport = t.transport
+	}
+	return nil
+}
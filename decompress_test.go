@@ -0,0 +1,114 @@
+package httpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecompressTransport(t *testing.T) {
+	response := strings.Repeat("hello world ", 100)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Accept-Encoding"); got != "gzip, deflate" {
+			t.Errorf("unexpected accept-encoding. expected: gzip, deflate, got: %v", got)
+		}
+		var codec Codec
+		switch r.URL.Path {
+		case "/gzip":
+			codec = GzipCodec(gzip.DefaultCompression)
+		case "/deflate":
+			codec = DeflateCodec(zlib.DefaultCompression)
+		case "/empty":
+			w.Header().Set("Content-Encoding", "gzip")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		default:
+			w.Write([]byte(response))
+			return
+		}
+		var b bytes.Buffer
+		cw, _ := codec.NewWriter(&b)
+		cw.Write([]byte(response))
+		cw.Close()
+		w.Header().Set("Content-Encoding", codec.Encoding())
+		w.Write(b.Bytes())
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	for _, p := range []string{"/gzip", "/deflate", "/identity"} {
+		client := &http.Client{Transport: DecompressTransport(nil, 0)}
+		resp, err := client.Get(s.URL + p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resp.Header.Get("Content-Encoding"); got != "" {
+			t.Errorf("%v: unexpected content-encoding: %v", p, got)
+		}
+		if got, err := readAllString(resp.Body); got != response {
+			t.Errorf("%v: unexpected response body. expected: %v, got: %v", p, response, got)
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	t.Run("Empty", func(t *testing.T) {
+		client := &http.Client{Transport: DecompressTransport(nil, 0)}
+		resp, err := client.Get(s.URL + "/empty")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if _, err := ioutil.ReadAll(resp.Body); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("TooLarge", func(t *testing.T) {
+		client := &http.Client{Transport: DecompressTransport(nil, 100)}
+		resp, err := client.Get(s.URL + "/gzip")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		if !errors.Is(err, ErrDecompressedTooLarge) {
+			t.Errorf("unexpected error. expected: %v, got: %v", ErrDecompressedTooLarge, err)
+		}
+		if len(b) != 100 {
+			t.Errorf("unexpected read size. expected: 100, got: %v", len(b))
+		}
+	})
+	t.Run("WithDebugTransport", func(t *testing.T) {
+		var buf bytes.Buffer
+		client := &http.Client{}
+		if err := InjectDebugTransport(client, &buf); err != nil {
+			t.Fatal(err)
+		}
+		if err := InjectDecompressTransport(client, 0); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Get(s.URL + "/gzip")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if !strings.Contains(buf.String(), response) {
+			t.Errorf("dump does not contain decoded body")
+		}
+		if err := RemoveDecompressTransport(client); err != nil {
+			t.Fatal(err)
+		}
+		if dt := client.Transport.(*debugTransport); dt.transport != nil {
+			t.Errorf("unexpected transport. expected: nil, got: %v", dt.transport)
+		}
+	})
+}
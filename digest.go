@@ -0,0 +1,233 @@
+package httpc
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+	nc        uint32
+}
+
+type digestTransport struct {
+	username  string
+	password  string
+	transport http.RoundTripper
+
+	mu         sync.Mutex
+	challenges map[string]*digestChallenge
+}
+
+func DigestTransport(transport http.RoundTripper, username, password string) http.RoundTripper {
+	return &digestTransport{
+		username:   username,
+		password:   password,
+		transport:  transport,
+		challenges: map[string]*digestChallenge{},
+	}
+}
+
+func (t *digestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt := t.transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	first := req
+	if auth, ok, err := t.authorization(req); err != nil {
+		closeRequestBody(req)
+		return nil, err
+	} else if ok {
+		first = req.Clone(req.Context())
+		first.Header.Set("Authorization", auth)
+	}
+	resp, err := rt.RoundTrip(first)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	c, ok := parseDigestChallenge(resp.Header["Www-Authenticate"])
+	if !ok {
+		return resp, nil
+	}
+	r, err := rewindBody(req)
+	if err != nil {
+		return resp, nil
+	}
+	discardBody(resp)
+
+	t.mu.Lock()
+	t.challenges[req.URL.Host] = c
+	t.mu.Unlock()
+
+	auth, _, err := t.authorization(r)
+	if err != nil {
+		closeRequestBody(r)
+		return nil, err
+	}
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", auth)
+	return rt.RoundTrip(r)
+}
+
+func (t *digestTransport) authorization(req *http.Request) (string, bool, error) {
+	t.mu.Lock()
+	c, ok := t.challenges[req.URL.Host]
+	if !ok {
+		t.mu.Unlock()
+		return "", false, nil
+	}
+	c.nc++
+	nc := c.nc
+	ch := *c
+	t.mu.Unlock()
+
+	auth, err := ch.authorize(t.username, t.password, req.Method, req.URL.RequestURI(), nc)
+	if err != nil {
+		return "", false, err
+	}
+	return auth, true, nil
+}
+
+func (c *digestChallenge) authorize(username, password, method, uri string, nc uint32) (string, error) {
+	algorithm := strings.ToUpper(c.algorithm)
+	var newHash func() hash.Hash
+	switch strings.TrimSuffix(algorithm, "-SESS") {
+	case "", "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return "", fmt.Errorf("unsupported digest algorithm: %v", c.algorithm)
+	}
+	h := func(s string) string {
+		d := newHash()
+		d.Write([]byte(s))
+		return hex.EncodeToString(d.Sum(nil))
+	}
+
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	cnonce := hex.EncodeToString(b[:])
+	ncs := fmt.Sprintf("%08x", nc)
+
+	ha1 := h(username + ":" + c.realm + ":" + password)
+	if strings.HasSuffix(algorithm, "-SESS") {
+		ha1 = h(ha1 + ":" + c.nonce + ":" + cnonce)
+	}
+	ha2 := h(method + ":" + uri)
+
+	var response string
+	if c.qop == "auth" {
+		response = h(strings.Join([]string{ha1, c.nonce, ncs, cnonce, c.qop, ha2}, ":"))
+	} else {
+		response = h(ha1 + ":" + c.nonce + ":" + ha2)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `Digest username="%s", realm="%s", nonce="%s", uri="%s"`,
+		quoteEscaper.Replace(username), quoteEscaper.Replace(c.realm), quoteEscaper.Replace(c.nonce), quoteEscaper.Replace(uri))
+	if c.algorithm != "" {
+		fmt.Fprintf(&sb, ", algorithm=%s", c.algorithm)
+	}
+	fmt.Fprintf(&sb, `, response="%s"`, response)
+	if c.opaque != "" {
+		fmt.Fprintf(&sb, `, opaque="%s"`, quoteEscaper.Replace(c.opaque))
+	}
+	if c.qop == "auth" {
+		fmt.Fprintf(&sb, `, qop=auth, nc=%s, cnonce="%s"`, ncs, cnonce)
+	}
+	return sb.String(), nil
+}
+
+func parseDigestChallenge(headers []string) (*digestChallenge, bool) {
+	var found *digestChallenge
+	for _, h := range headers {
+		if len(h) < 7 || !strings.EqualFold(h[:7], "Digest ") {
+			continue
+		}
+		params := parseAuthParams(h[7:])
+		c := &digestChallenge{
+			realm:     params["realm"],
+			nonce:     params["nonce"],
+			opaque:    params["opaque"],
+			algorithm: params["algorithm"],
+		}
+		if c.nonce == "" {
+			continue
+		}
+		if qop, ok := params["qop"]; ok {
+			supported := false
+			for _, q := range strings.Split(qop, ",") {
+				if strings.TrimSpace(q) == "auth" {
+					supported = true
+				}
+			}
+			if !supported {
+				continue
+			}
+			c.qop = "auth"
+		}
+		switch strings.TrimSuffix(strings.ToUpper(c.algorithm), "-SESS") {
+		case "SHA-256":
+			return c, true
+		case "", "MD5":
+			if found == nil {
+				found = c
+			}
+		}
+	}
+	return found, found != nil
+}
+
+func parseAuthParams(s string) map[string]string {
+	params := map[string]string{}
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			return params
+		}
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			return params
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			value = b.String()
+			if i < len(s) {
+				i++
+			}
+			s = s[i:]
+		} else {
+			end := strings.IndexByte(s, ',')
+			if end < 0 {
+				end = len(s)
+			}
+			value = strings.TrimSpace(s[:end])
+			s = s[end:]
+		}
+		params[key] = value
+	}
+}
@@ -0,0 +1,106 @@
+package httpc
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDigestTransport(t *testing.T) {
+	const (
+		username = "Mufasa"
+		password = "Circle of Life"
+		realm    = "http-auth@example.org"
+		nonce    = "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v"
+		opaque   = "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"
+	)
+
+	for _, algorithm := range []string{"MD5", "SHA-256"} {
+		t.Run(algorithm, func(t *testing.T) {
+			newHash := md5.New
+			if algorithm == "SHA-256" {
+				newHash = func() hash.Hash { return sha256.New() }
+			}
+			h := func(s string) string {
+				d := newHash()
+				d.Write([]byte(s))
+				return hex.EncodeToString(d.Sum(nil))
+			}
+
+			challenges, requests := 0, 0
+			var ncs []string
+			mux := http.NewServeMux()
+			mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				b, _ := readAllString(r.Body)
+				params := parseAuthParams(strings.TrimPrefix(r.Header.Get("Authorization"), "Digest "))
+				ha1 := h(username + ":" + realm + ":" + password)
+				ha2 := h(r.Method + ":" + r.URL.RequestURI())
+				expected := h(strings.Join([]string{ha1, nonce, params["nc"], params["cnonce"], "auth", ha2}, ":"))
+				if params["response"] != expected || params["opaque"] != opaque || params["uri"] != r.URL.RequestURI() {
+					challenges++
+					w.Header().Add("WWW-Authenticate", `Basic realm="`+realm+`"`)
+					w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Digest realm="%s", qop="auth, auth-int", algorithm=%s, nonce="%s", opaque="%s"`, realm, algorithm, nonce, opaque))
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				ncs = append(ncs, params["nc"])
+				fmt.Fprint(w, b)
+			})
+			s := httptest.NewServer(mux)
+			defer s.Close()
+
+			client := &http.Client{Transport: DigestTransport(nil, username, password)}
+			for i := 0; i < 2; i++ {
+				req, err := NewRequest(context.Background(), http.MethodPost, s.URL+"/dir/index.html?q=1",
+					WithJSON(map[string]int{"n": i}),
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp, err := client.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := resp.StatusCode; got != http.StatusOK {
+					t.Fatalf("unexpected status code. expected: %v, got: %v", http.StatusOK, got)
+				}
+				expected := fmt.Sprintf(`{"n":%d}`+"\n", i)
+				if got, err := readAllString(resp.Body); got != expected {
+					t.Errorf("unexpected response body. expected: %v, got: %v", expected, got)
+				} else if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+			}
+			if challenges != 1 || requests != 3 {
+				t.Errorf("unexpected round trips. expected: 1 challenge / 3 requests, got: %v / %v", challenges, requests)
+			}
+			if expected := []string{"00000001", "00000002"}; strings.Join(ncs, ",") != strings.Join(expected, ",") {
+				t.Errorf("unexpected nonce counts. expected: %v, got: %v", expected, ncs)
+			}
+		})
+	}
+}
+
+func TestParseAuthParams(t *testing.T) {
+	got := parseAuthParams(`realm="a, \"b\"", qop="auth,auth-int", algorithm=MD5, stale=true`)
+	expected := map[string]string{
+		"realm":     `a, "b"`,
+		"qop":       "auth,auth-int",
+		"algorithm": "MD5",
+		"stale":     "true",
+	}
+	for k, v := range expected {
+		if got[k] != v {
+			t.Errorf("unexpected %v. expected: %v, got: %v", k, v, got[k])
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package httpc
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type HMACConfig struct {
+	SignatureHeader string
+	TimestampHeader string
+	BodyHashHeader  string
+	SignedHeaders   []string
+	Now             func() time.Time
+}
+
+var DefaultHMACConfig = HMACConfig{
+	SignatureHeader: "Authorization",
+	TimestampHeader: "X-Timestamp",
+	BodyHashHeader:  "X-Content-SHA256",
+}
+
+func WithHMACSignature(keyID string, secret []byte, config *HMACConfig) RequestOption {
+	c := DefaultHMACConfig
+	if config != nil {
+		c = *config
+	}
+	return WithFinalizer(func(req *http.Request) error {
+		return signHMAC(req, keyID, secret, c)
+	})
+}
+
+func signHMAC(req *http.Request, keyID string, secret []byte, c HMACConfig) error {
+	if c.SignatureHeader == "" {
+		return fmt.Errorf("missing signature header")
+	}
+	now := c.Now
+	if now == nil {
+		now = TimeNow
+	}
+	bodyHash, err := hashRequestBody(req)
+	if err != nil {
+		return err
+	}
+	signed := append([]string(nil), c.SignedHeaders...)
+	if c.TimestampHeader != "" {
+		req.Header.Set(c.TimestampHeader, strconv.FormatInt(now().Unix(), 10))
+		signed = append(signed, c.TimestampHeader)
+	}
+	if c.BodyHashHeader != "" {
+		req.Header.Set(c.BodyHashHeader, bodyHash)
+		signed = append(signed, c.BodyHashHeader)
+	}
+	canonical, signedHeaders := canonicalHMACRequest(req, signed, bodyHash)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(canonical))
+	req.Header.Set(c.SignatureHeader, fmt.Sprintf("HMAC-SHA256 KeyId=%s,SignedHeaders=%s,Signature=%s",
+		keyID, signedHeaders, hex.EncodeToString(mac.Sum(nil))))
+	return nil
+}
+
+func canonicalHMACRequest(req *http.Request, headers []string, bodyHash string) (string, string) {
+	names := make([]string, 0, len(headers))
+	seen := make(map[string]bool, len(headers))
+	for _, h := range headers {
+		name := strings.ToLower(h)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(req.Method)
+	b.WriteByte('\n')
+	b.WriteString(req.URL.EscapedPath())
+	b.WriteByte('\n')
+	b.WriteString(req.URL.Query().Encode())
+	b.WriteByte('\n')
+	for _, name := range names {
+		var values []string
+		for _, v := range req.Header[textproto.CanonicalMIMEHeaderKey(name)] {
+			values = append(values, strings.TrimSpace(v))
+		}
+		if name == "host" && len(values) == 0 {
+			values = []string{req.Host}
+		}
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(strings.Join(values, ","))
+		b.WriteByte('\n')
+	}
+	signedHeaders := strings.Join(names, ";")
+	b.WriteString(signedHeaders)
+	b.WriteByte('\n')
+	b.WriteString(bodyHash)
+	return b.String(), signedHeaders
+}
+
+func hashRequestBody(req *http.Request) (string, error) {
+	h := sha256.New()
+	if req.Body != nil && req.Body != http.NoBody {
+		if err := copyRequestBody(h, req); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func copyRequestBody(w io.Writer, req *http.Request) error {
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return fmt.Errorf("read request body: %w", err)
+		}
+		_, err = io.Copy(w, body)
+		body.Close()
+		if err != nil {
+			return fmt.Errorf("read request body: %w", err)
+		}
+		if req.Body, err = req.GetBody(); err != nil {
+			return fmt.Errorf("rewind request body: %w", err)
+		}
+		return nil
+	}
+	b, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return fmt.Errorf("read request body: %w", err)
+	}
+	w.Write(b)
+	req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(b)), nil
+	}
+	return nil
+}
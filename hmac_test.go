@@ -0,0 +1,70 @@
+package httpc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestWithHMACSignature(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1600000000, 0)
+	config := &HMACConfig{
+		SignatureHeader: "X-Signature",
+		TimestampHeader: "X-Timestamp",
+		BodyHashHeader:  "X-Content-SHA256",
+		SignedHeaders:   []string{"Content-Type", "Host"},
+		Now:             func() time.Time { return now },
+	}
+
+	req, err := NewRequest(context.Background(), http.MethodPost, "http://api.example/v1/orders?b=2",
+		WithHMACSignature("key-1", secret, config),
+		WithForm(url.Values{"item": {"apple"}}),
+		AddQuery("a", "1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bodySum := sha256.Sum256([]byte("item=apple"))
+	bodyHash := hex.EncodeToString(bodySum[:])
+	canonical := "POST\n/v1/orders\na=1&b=2\n" +
+		"content-type:application/x-www-form-urlencoded\n" +
+		"host:api.example\n" +
+		"x-content-sha256:" + bodyHash + "\n" +
+		"x-timestamp:1600000000\n" +
+		"content-type;host;x-content-sha256;x-timestamp\n" +
+		bodyHash
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(canonical))
+	expected := "HMAC-SHA256 KeyId=key-1,SignedHeaders=content-type;host;x-content-sha256;x-timestamp,Signature=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := req.Header.Get("X-Signature"); got != expected {
+		t.Errorf("unexpected signature. expected: %v, got: %v", expected, got)
+	}
+	if got := req.Header.Get("X-Timestamp"); got != "1600000000" {
+		t.Errorf("unexpected timestamp. expected: 1600000000, got: %v", got)
+	}
+	if got, err := readAllString(req.Body); got != "item=apple" {
+		t.Errorf("unexpected request body. expected: item=apple, got: %v", got)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("DefaultConfig", func(t *testing.T) {
+		req, err := NewRequest(context.Background(), http.MethodGet, "http://api.example/",
+			WithHMACSignature("key-1", secret, nil),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := req.Header.Get("Authorization"); got == "" {
+			t.Error("missing signature")
+		}
+	})
+}
@@ -0,0 +1,200 @@
+package httpc
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"strings"
+)
+
+type MultipartPart struct {
+	Name        string
+	FileName    string
+	ContentType string
+	Header      textproto.MIMEHeader
+	Body        io.Reader
+	Size        int64
+}
+
+func MultipartField(name, value string) MultipartPart {
+	return MultipartPart{
+		Name: name,
+		Body: strings.NewReader(value),
+	}
+}
+
+func MultipartFile(name, fileName string, body io.Reader) MultipartPart {
+	return MultipartPart{
+		Name:        name,
+		FileName:    fileName,
+		ContentType: "application/octet-stream",
+		Body:        body,
+	}
+}
+
+func WithMultipart(parts ...MultipartPart) RequestOption {
+	return func(o *RequestOptions) error {
+		body, err := newMultipartBody(parts)
+		if err != nil {
+			return err
+		}
+		o.Header.Set("Content-Type", "multipart/form-data; boundary="+body.boundary)
+		o.Body = body
+		return nil
+	}
+}
+
+type multipartPart struct {
+	header textproto.MIMEHeader
+	body   io.Reader
+	rewind func() (io.ReadCloser, error)
+}
+
+type multipartBody struct {
+	*streamBody
+	boundary string
+	parts    []multipartPart
+	size     int64
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func newMultipartBody(parts []MultipartPart) (*multipartBody, error) {
+	b := &multipartBody{
+		boundary: multipart.NewWriter(ioutil.Discard).Boundary(),
+		parts:    make([]multipartPart, 0, len(parts)),
+	}
+	b.streamBody = newStreamBody(b.writeTo)
+	known := true
+	size := int64(0)
+	for _, p := range parts {
+		if p.Name == "" {
+			return nil, fmt.Errorf("missing multipart part name")
+		}
+		if p.Body == nil {
+			return nil, fmt.Errorf("missing multipart part body: %v", p.Name)
+		}
+		h := make(textproto.MIMEHeader, len(p.Header)+2)
+		for k, vv := range p.Header {
+			h[k] = append([]string(nil), vv...)
+		}
+		disposition := fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(p.Name))
+		if p.FileName != "" {
+			disposition += fmt.Sprintf(`; filename="%s"`, quoteEscaper.Replace(p.FileName))
+		}
+		h.Set("Content-Disposition", disposition)
+		if p.ContentType != "" {
+			h.Set("Content-Type", p.ContentType)
+		}
+		rewind, err := rewinder(p.Body)
+		if err != nil {
+			return nil, fmt.Errorf("prepare multipart part %v: %w", p.Name, err)
+		}
+		b.parts = append(b.parts, multipartPart{header: h, body: p.Body, rewind: rewind})
+
+		n := p.Size
+		if n <= 0 {
+			var ok bool
+			if n, ok = readerSize(p.Body); !ok {
+				known = false
+			}
+		}
+		size += n
+	}
+	b.size = -1
+	if known {
+		overhead, err := b.overhead()
+		if err != nil {
+			return nil, err
+		}
+		b.size = size + overhead
+	}
+	return b, nil
+}
+
+func readerSize(r io.Reader) (int64, bool) {
+	switch v := r.(type) {
+	case interface{ Len() int }:
+		return int64(v.Len()), true
+	case interface{ Size() int64 }:
+		return v.Size(), v.Size() >= 0
+	case *os.File:
+		fi, err := v.Stat()
+		if err != nil || !fi.Mode().IsRegular() {
+			return 0, false
+		}
+		offset, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0, false
+		}
+		return fi.Size() - offset, true
+	}
+	return 0, false
+}
+
+type countWriter struct {
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+func (b *multipartBody) overhead() (int64, error) {
+	var cw countWriter
+	mw := multipart.NewWriter(&cw)
+	if err := mw.SetBoundary(b.boundary); err != nil {
+		return 0, err
+	}
+	for _, p := range b.parts {
+		if _, err := mw.CreatePart(p.header); err != nil {
+			return 0, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return 0, err
+	}
+	return cw.n, nil
+}
+
+func (b *multipartBody) writeTo(w io.Writer) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(b.boundary); err != nil {
+		return err
+	}
+	for _, p := range b.parts {
+		pw, err := mw.CreatePart(p.header)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(pw, p.body); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+func (b *multipartBody) Size() int64 {
+	return b.size
+}
+
+func (b *multipartBody) getBody() (io.ReadCloser, error) {
+	parts := make([]multipartPart, len(b.parts))
+	for i, p := range b.parts {
+		if p.rewind == nil {
+			return nil, ErrBodyNotRewindable
+		}
+		body, err := p.rewind()
+		if err != nil {
+			return nil, err
+		}
+		parts[i] = multipartPart{header: p.header, body: body, rewind: p.rewind}
+	}
+	nb := &multipartBody{boundary: b.boundary, parts: parts, size: b.size}
+	nb.streamBody = newStreamBody(nb.writeTo)
+	return nb, nil
+}
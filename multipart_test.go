@@ -0,0 +1,126 @@
+package httpc
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWithMultipart(t *testing.T) {
+	rawurl := "http://web.example"
+
+	tf, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tf.Name())
+	defer tf.Close()
+	tf.WriteString("file content")
+	tf.Seek(0, io.SeekStart)
+
+	readParts := func(t *testing.T, req *http.Request, body io.Reader) map[string]string {
+		mt, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mt != "multipart/form-data" {
+			t.Fatalf("unexpected content-type. expected: multipart/form-data, got: %v", mt)
+		}
+		parts := map[string]string{}
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := ioutil.ReadAll(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parts[p.FormName()+":"+p.FileName()+":"+p.Header.Get("Content-Type")+":"+p.Header.Get("X-Part")] = string(b)
+		}
+		return parts
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("X-Part", "1")
+	newOption := func() RequestOption {
+		tf.Seek(0, io.SeekStart)
+		return WithMultipart(
+			MultipartField("title", "hello"),
+			MultipartFile("file", "a.txt", tf),
+			MultipartPart{
+				Name:        "meta",
+				ContentType: "application/json",
+				Header:      header,
+				Body:        strings.NewReader(`{}`),
+			},
+		)
+	}
+	expected := map[string]string{
+		"title:::":                             "hello",
+		"file:a.txt:application/octet-stream:": "file content",
+		"meta::application/json:1":             `{}`,
+	}
+
+	t.Run("PositiveCase", func(t *testing.T) {
+		req, err := NewRequest(context.Background(), http.MethodPost, rawurl, newOption())
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := readParts(t, req, req.Body)
+		for k, v := range expected {
+			if got[k] != v {
+				t.Errorf("unexpected part %v. expected: %v, got: %v", k, v, got[k])
+			}
+		}
+
+		body, err := req.GetBody()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = readParts(t, req, body)
+		for k, v := range expected {
+			if got[k] != v {
+				t.Errorf("unexpected rewound part %v. expected: %v, got: %v", k, v, got[k])
+			}
+		}
+	})
+	t.Run("EnforceContentLength", func(t *testing.T) {
+		req, err := NewRequest(context.Background(), http.MethodPost, rawurl, newOption(), EnforceContentLength)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := req.Body.(*multipartBody); !ok {
+			t.Errorf("multipart body is buffered: %T", req.Body)
+		}
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := req.ContentLength; got != int64(len(b)) {
+			t.Errorf("unexpected ContentLength. expected: %v, got: %v", len(b), got)
+		}
+	})
+	t.Run("MissingName", func(t *testing.T) {
+		req, err := NewRequest(context.Background(), http.MethodPost, rawurl,
+			WithMultipart(MultipartField("", "value")),
+		)
+		if err == nil {
+			t.Errorf("accept missing name")
+		}
+		if req != nil {
+			t.Errorf("return invalid request")
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package httpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+type NDJSONIterator func(yield func(v interface{}) error) error
+
+func WithNDJSON(data interface{}) RequestOption {
+	return func(o *RequestOptions) error {
+		iter, err := ndjsonIterator(data)
+		if err != nil {
+			return err
+		}
+		o.setHeaderIfNotExists("Content-Type", "application/x-ndjson")
+		o.Body = newStreamBody(func(w io.Writer) error {
+			enc := json.NewEncoder(w)
+			return iter(enc.Encode)
+		})
+		return nil
+	}
+}
+
+func ndjsonIterator(data interface{}) (NDJSONIterator, error) {
+	switch v := data.(type) {
+	case NDJSONIterator:
+		return v, nil
+	case func(yield func(v interface{}) error) error:
+		return v, nil
+	}
+	rv := reflect.ValueOf(data)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		return func(yield func(v interface{}) error) error {
+			for i := 0; i < rv.Len(); i++ {
+				if err := yield(rv.Index(i).Interface()); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported ndjson data: %T", data)
+}
+
+type NDJSONDecoder struct {
+	ctx  context.Context
+	body io.ReadCloser
+	dec  *json.Decoder
+
+	once sync.Once
+	done chan struct{}
+}
+
+func NewNDJSONDecoder(ctx context.Context, resp *http.Response) (*NDJSONDecoder, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("missing ctx")
+	}
+	if resp == nil {
+		return nil, fmt.Errorf("missing response")
+	}
+	if err := CheckStatus(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	ct := resp.Header.Get("Content-Type")
+	if mt, _, err := mime.ParseMediaType(ct); err != nil || !isNDJSONMediaType(mt) {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected content-type: %q", ct)
+	}
+	d := &NDJSONDecoder{
+		ctx:  ctx,
+		body: resp.Body,
+		dec:  json.NewDecoder(resp.Body),
+		done: make(chan struct{}),
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			d.body.Close()
+		case <-d.done:
+		}
+	}()
+	return d, nil
+}
+
+func (d *NDJSONDecoder) Decode(v interface{}) error {
+	if err := d.ctx.Err(); err != nil {
+		return err
+	}
+	if err := d.dec.Decode(v); err != nil {
+		if ctxErr := d.ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return err
+	}
+	return nil
+}
+
+func (d *NDJSONDecoder) Close() error {
+	var err error
+	d.once.Do(func() {
+		close(d.done)
+		err = d.body.Close()
+	})
+	return err
+}
+
+func isNDJSONMediaType(mt string) bool {
+	switch mt {
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package httpc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestWithNDJSON(t *testing.T) {
+	rawurl := "http://web.example"
+	expected := `{"id":1}` + "\n" + `{"id":2}` + "\n"
+
+	type item struct {
+		ID int `json:"id"`
+	}
+
+	ts := []interface{}{
+		[]item{{1}, {2}},
+		NDJSONIterator(func(yield func(v interface{}) error) error {
+			for i := 1; i <= 2; i++ {
+				if err := yield(item{i}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	}
+	for _, data := range ts {
+		req, err := NewRequest(context.Background(), http.MethodPost, rawurl, WithNDJSON(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := req.Header.Get("Content-Type"); got != "application/x-ndjson" {
+			t.Errorf("unexpected content-type. expected: application/x-ndjson, got: %v", got)
+		}
+		if got, err := readAllString(req.Body); got != expected {
+			t.Errorf("unexpected ndjson body. expected: %v, got: %v", expected, got)
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("Unsupported", func(t *testing.T) {
+		req, err := NewRequest(context.Background(), http.MethodPost, rawurl, WithNDJSON(1))
+		if err == nil {
+			t.Errorf("accept unsupported data")
+		}
+		if req != nil {
+			t.Errorf("return invalid request")
+		}
+	})
+}
+
+func TestNDJSONDecoder(t *testing.T) {
+	type item struct {
+		ID int `json:"id"`
+	}
+
+	t.Run("PositiveCase", func(t *testing.T) {
+		resp := newTestResponse(http.StatusOK, "application/x-ndjson", `{"id":1}`+"\n"+`{"id":2}`+"\n")
+		dec, err := NewNDJSONDecoder(context.Background(), resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer dec.Close()
+		var got []item
+		for {
+			var v item
+			err := dec.Decode(&v)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, v)
+		}
+		if expected := []item{{1}, {2}}; !reflect.DeepEqual(got, expected) {
+			t.Errorf("unexpected items. expected: %v, got: %v", expected, got)
+		}
+	})
+	t.Run("Cancel", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		defer pw.Close()
+		resp := newTestResponse(http.StatusOK, "application/x-ndjson", "")
+		resp.Body = pr
+		ctx, cancel := context.WithCancel(context.Background())
+		dec, err := NewNDJSONDecoder(ctx, resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer dec.Close()
+		go func() {
+			pw.Write([]byte(`{"id":1}` + "\n"))
+			cancel()
+		}()
+		var v item
+		if err := dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		if err := dec.Decode(&v); err != context.Canceled {
+			t.Errorf("unexpected error. expected: %v, got: %v", context.Canceled, err)
+		}
+	})
+	t.Run("UnexpectedContentType", func(t *testing.T) {
+		resp := newTestResponse(http.StatusOK, "application/json", `{}`)
+		if _, err := NewNDJSONDecoder(context.Background(), resp); err == nil {
+			t.Error("accept unexpected content-type")
+		}
+	})
+}
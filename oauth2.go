@@ -0,0 +1,226 @@
+package httpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var DefaultTokenExpiryDelta = 10 * time.Second
+var DefaultTokenTimeout = 30 * time.Second
+
+type ClientCredentialsConfig struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+
+	EndpointParams url.Values
+	AuthInParams   bool
+	ExpiryDelta    time.Duration
+	TokenTimeout   time.Duration
+	Client         *http.Client
+}
+
+type tokenCall struct {
+	done  chan struct{}
+	token *Token
+	err   error
+}
+
+type clientCredentialsSource struct {
+	config  ClientCredentialsConfig
+	builder *RequestBuilder
+
+	mu     sync.Mutex
+	token  *Token
+	flight *tokenCall
+}
+
+func ClientCredentialsTokenSource(config ClientCredentialsConfig) (TokenSource, error) {
+	return newClientCredentialsSource(config)
+}
+
+func newClientCredentialsSource(config ClientCredentialsConfig) (*clientCredentialsSource, error) {
+	if config.ClientID == "" {
+		return nil, fmt.Errorf("missing client id")
+	}
+	rb, err := NewRequestBuilder(config.TokenURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("token url: %w", err)
+	}
+	if config.ExpiryDelta == 0 {
+		config.ExpiryDelta = DefaultTokenExpiryDelta
+	}
+	if config.TokenTimeout == 0 {
+		config.TokenTimeout = DefaultTokenTimeout
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	return &clientCredentialsSource{config: config, builder: rb}, nil
+}
+
+func (s *clientCredentialsSource) valid(t *Token) bool {
+	return t != nil && (t.Expiry.IsZero() || TimeNow().Add(s.config.ExpiryDelta).Before(t.Expiry))
+}
+
+func (s *clientCredentialsSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	if s.valid(s.token) {
+		t := s.token
+		s.mu.Unlock()
+		return t, nil
+	}
+	call := s.flight
+	if call == nil {
+		call = &tokenCall{done: make(chan struct{})}
+		s.flight = call
+		go s.refresh(call)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.token, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *clientCredentialsSource) refresh(call *tokenCall) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.TokenTimeout)
+	call.token, call.err = s.fetch(ctx)
+	cancel()
+	s.mu.Lock()
+	if call.err == nil {
+		s.token = call.token
+	}
+	s.flight = nil
+	s.mu.Unlock()
+	close(call.done)
+}
+
+func (s *clientCredentialsSource) invalidate(t *Token) {
+	s.mu.Lock()
+	if s.token == t {
+		s.token = nil
+	}
+	s.mu.Unlock()
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+func (s *clientCredentialsSource) fetch(ctx context.Context) (*Token, error) {
+	c := s.config
+	params := url.Values{}
+	for k, vs := range c.EndpointParams {
+		params[k] = append([]string(nil), vs...)
+	}
+	params.Set("grant_type", "client_credentials")
+	if len(c.Scopes) > 0 {
+		params.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	opts := []RequestOption{SetHeaderField("Accept", "application/json")}
+	if c.AuthInParams {
+		params.Set("client_id", c.ClientID)
+		params.Set("client_secret", c.ClientSecret)
+	} else {
+		opts = append(opts, WithBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret)))
+	}
+	opts = append(opts, WithForm(params))
+
+	start := TimeNow()
+	req, err := s.builder.NewRequest(ctx, http.MethodPost, "", opts...)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request token: %w", err)
+	}
+	var tr tokenResponse
+	if err := DecodeJSON(resp, &tr); err != nil {
+		return nil, fmt.Errorf("request token: %w", err)
+	}
+	if tr.AccessToken == "" {
+		return nil, fmt.Errorf("request token: missing access_token")
+	}
+	t := &Token{Type: tr.TokenType, Value: tr.AccessToken}
+	if strings.EqualFold(t.Type, "bearer") || t.Type == "" {
+		t.Type = "Bearer"
+	}
+	if tr.ExpiresIn > 0 {
+		t.Expiry = start.Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	return t, nil
+}
+
+type tokenInvalidator interface {
+	invalidate(t *Token)
+}
+
+type tokenTransport struct {
+	source    TokenSource
+	transport http.RoundTripper
+}
+
+func ClientCredentialsTransport(transport http.RoundTripper, config ClientCredentialsConfig) (http.RoundTripper, error) {
+	source, err := newClientCredentialsSource(config)
+	if err != nil {
+		return nil, err
+	}
+	return &tokenTransport{source: source, transport: transport}, nil
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt := t.transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	token, err := t.source.Token(req.Context())
+	if err != nil {
+		closeRequestBody(req)
+		return nil, err
+	}
+	resp, err := rt.RoundTrip(withToken(req, token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	inv, ok := t.source.(tokenInvalidator)
+	if !ok {
+		return resp, nil
+	}
+	r, err := rewindBody(req)
+	if err != nil {
+		return resp, nil
+	}
+	discardBody(resp)
+	inv.invalidate(token)
+	token, err = t.source.Token(req.Context())
+	if err != nil {
+		closeRequestBody(r)
+		return nil, err
+	}
+	return rt.RoundTrip(withToken(r, token))
+}
+
+func withToken(req *http.Request, token *Token) *http.Request {
+	r := req.Clone(req.Context())
+	r.Header.Set("Authorization", token.Type+" "+token.Value)
+	return r
+}
+
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
@@ -0,0 +1,152 @@
+package httpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientCredentialsTransport(t *testing.T) {
+	var issued int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "client" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if got := r.PostFormValue("grant_type"); got != "client_credentials" {
+			t.Errorf("unexpected grant_type. expected: client_credentials, got: %v", got)
+		}
+		if got := r.PostFormValue("scope"); got != "read write" {
+			t.Errorf("unexpected scope. expected: read write, got: %v", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+		n := atomic.AddInt32(&issued, 1)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("token-%d", n),
+			"token_type":   "bearer",
+			"expires_in":   3600,
+		})
+	})
+	var revoked atomic.Value
+	revoked.Store("")
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if auth == "" || auth == revoked.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		b, _ := readAllString(r.Body)
+		fmt.Fprint(w, auth+" "+b)
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	rt, err := ClientCredentialsTransport(nil, ClientCredentialsConfig{
+		TokenURL:     s.URL + "/token",
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: rt}
+
+	call := func(t *testing.T, expected string) {
+		req, err := NewRequest(context.Background(), http.MethodPost, s.URL+"/api", WithBody(strings.NewReader("body")))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		if got, _ := readAllString(resp.Body); got != expected {
+			t.Errorf("unexpected response. expected: %v, got: %v", expected, got)
+		}
+	}
+
+	t.Run("Cached", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				call(t, "Bearer token-1 body")
+			}()
+		}
+		wg.Wait()
+		if got := atomic.LoadInt32(&issued); got != 1 {
+			t.Errorf("unexpected token requests. expected: 1, got: %v", got)
+		}
+	})
+	t.Run("RefreshOnUnauthorized", func(t *testing.T) {
+		revoked.Store("Bearer token-1")
+		call(t, "Bearer token-2 body")
+		if got := atomic.LoadInt32(&issued); got != 2 {
+			t.Errorf("unexpected token requests. expected: 2, got: %v", got)
+		}
+	})
+	t.Run("InvalidClient", func(t *testing.T) {
+		rt, err := ClientCredentialsTransport(nil, ClientCredentialsConfig{
+			TokenURL: s.URL + "/token",
+			ClientID: "unknown",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := (&http.Client{Transport: rt}).Get(s.URL + "/api")
+		if err == nil {
+			resp.Body.Close()
+			t.Errorf("accept invalid client")
+		}
+	})
+	t.Run("TokenTimeout", func(t *testing.T) {
+		var hang int32 = 1
+		mux := http.NewServeMux()
+		mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+			r.ParseForm()
+			if atomic.LoadInt32(&hang) == 1 {
+				<-r.Context().Done()
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"access_token":"token","token_type":"bearer"}`)
+		})
+		s := httptest.NewServer(mux)
+		defer s.Close()
+
+		ts, err := ClientCredentialsTokenSource(ClientCredentialsConfig{
+			TokenURL:     s.URL + "/token",
+			ClientID:     "client",
+			TokenTimeout: 50 * time.Millisecond,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ts.Token(context.Background()); err == nil {
+			t.Errorf("accept hanging token endpoint")
+		}
+		atomic.StoreInt32(&hang, 0)
+		token, err := ts.Token(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := token.Value; got != "token" {
+			t.Errorf("unexpected token. expected: token, got: %v", got)
+		}
+	})
+}
@@ -0,0 +1,245 @@
+package httpc
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func WithQueryStruct(v interface{}) RequestOption {
+	return func(o *RequestOptions) error {
+		q, err := EncodeQuery(v)
+		if err != nil {
+			return err
+		}
+		for k, vs := range q {
+			for _, s := range vs {
+				o.Queries.Add(k, s)
+			}
+		}
+		return nil
+	}
+}
+
+func EncodeQuery(v interface{}) (url.Values, error) {
+	q := url.Values{}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return q, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("query struct: unsupported type %T", v)
+	}
+	if err := encodeQueryStruct(q, rv); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+type queryTag struct {
+	name       string
+	omitempty  bool
+	comma      bool
+	brackets   bool
+	timeFormat string
+}
+
+func parseQueryTag(f reflect.StructField) (queryTag, bool) {
+	tag := f.Tag.Get("url")
+	if tag == "-" {
+		return queryTag{}, false
+	}
+	opts := strings.Split(tag, ",")
+	t := queryTag{name: opts[0]}
+	if t.name == "" {
+		t.name = f.Name
+	}
+	for _, opt := range opts[1:] {
+		switch opt {
+		case "omitempty":
+			t.omitempty = true
+		case "comma":
+			t.comma = true
+		case "brackets":
+			t.brackets = true
+		case "unix":
+			t.timeFormat = "unix"
+		}
+	}
+	if layout, ok := f.Tag.Lookup("layout"); ok {
+		t.timeFormat = layout
+	}
+	return t, true
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func encodeQueryStruct(q url.Values, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		tag, ok := parseQueryTag(f)
+		if !ok {
+			continue
+		}
+		fv := rv.Field(i)
+		if f.Anonymous && f.Tag.Get("url") == "" {
+			if ev := indirectQueryValue(fv); ev.IsValid() && ev.Kind() == reflect.Struct && !isQueryScalar(ev) {
+				if err := encodeQueryStruct(q, ev); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if tag.omitempty && isEmptyValue(fv) {
+			continue
+		}
+		fv = indirectQueryValue(fv)
+		if !fv.IsValid() {
+			continue
+		}
+
+		if (fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array) && !isQueryScalar(fv) {
+			values := make([]string, 0, fv.Len())
+			for j := 0; j < fv.Len(); j++ {
+				s, err := queryValueString(fv.Index(j), tag)
+				if err != nil {
+					return fmt.Errorf("query struct field %v: %w", f.Name, err)
+				}
+				values = append(values, s)
+			}
+			switch {
+			case tag.comma:
+				q.Add(tag.name, strings.Join(values, ","))
+			case tag.brackets:
+				for _, s := range values {
+					q.Add(tag.name+"[]", s)
+				}
+			default:
+				for _, s := range values {
+					q.Add(tag.name, s)
+				}
+			}
+			continue
+		}
+
+		s, err := queryValueString(fv, tag)
+		if err != nil {
+			return fmt.Errorf("query struct field %v: %w", f.Name, err)
+		}
+		q.Add(tag.name, s)
+	}
+	return nil
+}
+
+func indirectQueryValue(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func isQueryScalar(v reflect.Value) bool {
+	t := v.Type()
+	if t == timeType || t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return true
+	}
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+func queryValueString(v reflect.Value, tag queryTag) (string, error) {
+	v = indirectQueryValue(v)
+	if !v.IsValid() {
+		return "", nil
+	}
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		switch tag.timeFormat {
+		case "":
+			return t.Format(time.RFC3339), nil
+		case "unix":
+			return strconv.FormatInt(t.Unix(), 10), nil
+		default:
+			return t.Format(tag.timeFormat), nil
+		}
+	}
+	if m, ok := textMarshaler(v); ok {
+		b, err := m.MarshalText()
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes()), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported type %v", v.Type())
+}
+
+func textMarshaler(v reflect.Value) (encoding.TextMarshaler, bool) {
+	if v.Type().Implements(textMarshalerType) {
+		return v.Interface().(encoding.TextMarshaler), true
+	}
+	if reflect.PtrTo(v.Type()).Implements(textMarshalerType) {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		return p.Interface().(encoding.TextMarshaler), true
+	}
+	return nil, false
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return v.Interface().(time.Time).IsZero()
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package httpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWithQueryStruct(t *testing.T) {
+	rawurl := "http://web.example/?key=secret"
+
+	type Paging struct {
+		Page    int `url:"page,omitempty"`
+		PerPage int `url:"per_page"`
+	}
+	type Search struct {
+		Paging
+		Query   string     `url:"q"`
+		Sort    *string    `url:"sort,omitempty"`
+		Limit   *int       `url:"limit"`
+		Draft   bool       `url:"draft"`
+		Tags    []string   `url:"tag"`
+		IDs     []int      `url:"ids,comma"`
+		Labels  []string   `url:"label,brackets"`
+		Since   time.Time  `url:"since" layout:"2006-01-02"`
+		Until   *time.Time `url:"until,unix"`
+		Created time.Time  `url:"created,omitempty"`
+		IP      net.IP     `url:"ip"`
+		Ignored string     `url:"-"`
+		Score   float64
+		private string
+	}
+
+	limit := 20
+	until := time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)
+	search := &Search{
+		Paging:  Paging{PerPage: 10},
+		Query:   "golang",
+		Limit:   &limit,
+		Tags:    []string{"a", "b"},
+		IDs:     []int{1, 2, 3},
+		Labels:  []string{"x"},
+		Since:   time.Date(2020, 12, 31, 10, 0, 0, 0, time.UTC),
+		Until:   &until,
+		IP:      net.ParseIP("192.0.2.1"),
+		Ignored: "ignored",
+		Score:   1.5,
+	}
+	expected := url.Values{
+		"key":      {"secret"},
+		"per_page": {"10"},
+		"q":        {"golang"},
+		"limit":    {"20"},
+		"draft":    {"false"},
+		"tag":      {"a", "b"},
+		"ids":      {"1,2,3"},
+		"label[]":  {"x"},
+		"since":    {"2020-12-31"},
+		"until":    {"1609545600"},
+		"ip":       {"192.0.2.1"},
+		"Score":    {"1.5"},
+	}
+
+	req, err := NewRequest(context.Background(), http.MethodGet, rawurl, WithQueryStruct(search))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := req.URL.Query(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected queries. expected: %v, got: %v", expected, got)
+	}
+
+	t.Run("NotStruct", func(t *testing.T) {
+		req, err := NewRequest(context.Background(), http.MethodGet, rawurl, WithQueryStruct("q"))
+		if err == nil {
+			t.Errorf("accept not struct")
+		}
+		if req != nil {
+			t.Errorf("return invalid request")
+		}
+	})
+}
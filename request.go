@@ -2,7 +2,9 @@ package httpc
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"time"
 )
 
 func NewRequest(ctx context.Context, method, rawurl string, opts ...RequestOption) (*http.Request, error) {
@@ -12,3 +14,33 @@ func NewRequest(ctx context.Context, method, rawurl string, opts ...RequestOptio
 	}
 	return b.NewRequest(ctx, method, "", opts...)
 }
+
+func Do(client *http.Client, req *http.Request) (*http.Response, error) {
+	if client == nil {
+		return nil, fmt.Errorf("missing client")
+	}
+	if req == nil {
+		return nil, fmt.Errorf("missing request")
+	}
+	req, timeout := detachTimeout(req)
+	return doAttempt(client.Do, req, timeout)
+}
+
+type requestTimeoutKey struct{}
+
+type requestTimeout struct {
+	timeout time.Duration
+	parent  context.Context
+	ctx     context.Context
+}
+
+func detachTimeout(req *http.Request) (*http.Request, time.Duration) {
+	t, ok := req.Context().Value(requestTimeoutKey{}).(*requestTimeout)
+	if !ok {
+		return req, 0
+	}
+	if req.Context() != t.ctx {
+		return req, t.timeout
+	}
+	return req.WithContext(t.parent), t.timeout
+}
@@ -5,31 +5,67 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"path"
+	"sort"
+	"strings"
+)
+
+type JoinMode int
+
+const (
+	JoinClean JoinMode = iota
+	JoinResolveReference
 )
 
 type RequestBuilder struct {
-	baseURL *url.URL
-	header  http.Header
+	baseURL  *url.URL
+	header   http.Header
+	joinMode JoinMode
+	defaults []RequestOption
+}
+
+type RequestBuilderOption func(*RequestBuilder)
+
+func WithJoinMode(mode JoinMode) RequestBuilderOption {
+	return func(b *RequestBuilder) {
+		b.joinMode = mode
+	}
+}
+
+func WithDefaultOptions(opts ...RequestOption) RequestBuilderOption {
+	return func(b *RequestBuilder) {
+		b.defaults = append(b.defaults[:len(b.defaults):len(b.defaults)], opts...)
+	}
 }
 
-func NewRequestBuilder(rawurl string, header http.Header) (*RequestBuilder, error) {
+func NewRequestBuilder(rawurl string, header http.Header, opts ...RequestBuilderOption) (*RequestBuilder, error) {
 	u, err := url.ParseRequestURI(rawurl)
 	if err != nil {
 		return nil, fmt.Errorf("parse base url: %w", err)
 	}
-	return &RequestBuilder{
+	b := &RequestBuilder{
 		baseURL: u,
 		header:  header,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b, nil
 }
 
 func (b *RequestBuilder) BaseURL() *url.URL {
 	return b.baseURL
 }
 
+func (b *RequestBuilder) With(opts ...RequestOption) *RequestBuilder {
+	child := *b
+	WithDefaultOptions(opts...)(&child)
+	return &child
+}
+
 func (b *RequestBuilder) NewRequest(ctx context.Context, method, spath string, opts ...RequestOption) (*http.Request, error) {
 	if ctx == nil {
 		return nil, fmt.Errorf("missing ctx")
@@ -39,28 +75,42 @@ func (b *RequestBuilder) NewRequest(ctx context.Context, method, spath string, o
 	}
 
 	u := *b.baseURL
-	if len(spath) > 0 {
-		u.Path = path.Join(u.Path, spath)
-	}
 
 	h := cloneHeader(b.header)
 	options := &RequestOptions{
+		Context: ctx,
 		Header:  h,
 		Queries: u.Query(),
 	}
 
+	if err := ApplyRequestOption(options, b.defaults...); err != nil {
+		return nil, fmt.Errorf("apply default request option: %w", err)
+	}
 	if err := ApplyRequestOption(options, opts...); err != nil {
 		return nil, fmt.Errorf("apply request option: %w", err)
 	}
 
+	if err := b.joinPath(&u, spath, options.PathParams); err != nil {
+		return nil, err
+	}
+
 	u.RawQuery = options.Queries.Encode()
 
+	if options.Compression != nil && options.Body != nil {
+		body, err := newCompressedBody(options.Compression, options.Body)
+		if err != nil {
+			return nil, err
+		}
+		options.Header.Set("Content-Encoding", options.Compression.Encoding())
+		options.Body = body
+	}
+
 	contentLength := int64(0)
 	if options.EnforceContentLength && options.Body != nil {
 		type sizer interface {
 			Size() int64
 		}
-		if s, ok := options.Body.(sizer); ok {
+		if s, ok := options.Body.(sizer); ok && s.Size() >= 0 {
 			contentLength = s.Size()
 		} else {
 			var b bytes.Buffer
@@ -72,19 +122,235 @@ func (b *RequestBuilder) NewRequest(ctx context.Context, method, spath string, o
 			options.Body = &b
 		}
 	}
-	req, err := http.NewRequest(method, u.String(), options.Body)
+	body := options.Body
+	var getBody func() (io.ReadCloser, error)
+	if body != nil {
+		var err error
+		getBody, err = rewinder(body)
+		if err != nil {
+			return nil, fmt.Errorf("prepare rewindable body: %w", err)
+		}
+	}
+	req, err := http.NewRequest(method, u.String(), body)
 	if err != nil {
 		return nil, err
 	}
 	if options.EnforceContentLength {
 		req.ContentLength = contentLength
 	}
+	if req.GetBody == nil {
+		req.GetBody = getBody
+	}
 	req.Header = options.Header
+	for _, f := range options.Finalizers {
+		if err := f(req); err != nil {
+			return nil, fmt.Errorf("finalize request: %w", err)
+		}
+	}
+	if options.Timeout > 0 {
+		// The deadline bounds a plain http.Client.Do and is released by its own timer.
+		// Do, Retry and RetryTransport detach it and restart the timeout when each attempt is sent.
+		tctx, cancel := context.WithTimeout(ctx, options.Timeout)
+		_ = cancel
+		t := &requestTimeout{timeout: options.Timeout, parent: ctx}
+		ctx = context.WithValue(tctx, requestTimeoutKey{}, t)
+		t.ctx = ctx
+	}
 	req = req.WithContext(ctx)
 
 	return req, nil
 }
 
+func (b *RequestBuilder) joinPath(u *url.URL, spath string, params map[string]string) error {
+	templated := strings.Contains(spath, "{") || len(params) > 0
+	switch b.joinMode {
+	case JoinResolveReference:
+		if templated {
+			literals, values, err := expandPath(spath, params)
+			if err != nil {
+				return fmt.Errorf("expand path: %w", err)
+			}
+			raw, err := resolveLiterals(u, literals)
+			if err != nil {
+				return err
+			}
+			decoded := make([]string, len(raw))
+			for i, r := range raw {
+				if decoded[i], err = url.PathUnescape(r); err != nil {
+					return fmt.Errorf("parse path: %w", err)
+				}
+			}
+			u.Path = interleavePath(decoded, values, func(s string) string { return s })
+			u.RawPath = interleavePath(raw, values, url.PathEscape)
+			return nil
+		}
+		if len(spath) == 0 {
+			return nil
+		}
+		ref, err := parsePathReference(spath)
+		if err != nil {
+			return err
+		}
+		resolved := u.ResolveReference(ref)
+		u.Path, u.RawPath = resolved.Path, resolved.RawPath
+	default:
+		if templated {
+			literals, values, err := expandPath(spath, params)
+			if err != nil {
+				return fmt.Errorf("expand path: %w", err)
+			}
+			// Only the base path and the template literals are cleaned; values are spliced in verbatim
+			// so that they can never add, remove or climb out of a path segment.
+			decoded, err := joinLiterals(u.Path, literals, func(s string) string { return s })
+			if err != nil {
+				return err
+			}
+			raw, err := joinLiterals(u.EscapedPath(), literals, func(s string) string {
+				return (&url.URL{Path: s}).EscapedPath()
+			})
+			if err != nil {
+				return err
+			}
+			u.Path = interleavePath(decoded, values, func(s string) string { return s })
+			u.RawPath = interleavePath(raw, values, url.PathEscape)
+		} else if len(spath) > 0 {
+			u.Path = path.Join(u.Path, spath)
+		}
+	}
+	return nil
+}
+
+func expandPath(tmpl string, params map[string]string) ([]string, []string, error) {
+	var literals, values []string
+	used := make(map[string]bool, len(params))
+	for {
+		i := strings.IndexByte(tmpl, '{')
+		if i < 0 {
+			break
+		}
+		j := strings.IndexByte(tmpl[i:], '}')
+		if j < 0 {
+			return nil, nil, fmt.Errorf("unclosed path parameter: %v", tmpl[i:])
+		}
+		name := tmpl[i+1 : i+j]
+		v, ok := params[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("missing path parameter: %v", name)
+		}
+		if v == "" || v == "." || v == ".." {
+			return nil, nil, fmt.Errorf("invalid path parameter %v: %q", name, v)
+		}
+		used[name] = true
+		literals = append(literals, tmpl[:i])
+		values = append(values, v)
+		tmpl = tmpl[i+j+1:]
+	}
+	literals = append(literals, tmpl)
+
+	var unused []string
+	for name := range params {
+		if !used[name] {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return nil, nil, fmt.Errorf("unused path parameters: %v", strings.Join(unused, ", "))
+	}
+	return literals, values, nil
+}
+
+const pathParamMarker = "\x00"
+
+func joinLiterals(base string, literals []string, escape func(string) string) ([]string, error) {
+	if strings.Contains(base, pathParamMarker) {
+		return nil, fmt.Errorf("invalid base path: %q", base)
+	}
+	escaped := make([]string, len(literals))
+	for i, l := range literals {
+		if strings.Contains(l, pathParamMarker) {
+			return nil, fmt.Errorf("invalid path: %q", l)
+		}
+		escaped[i] = escape(l)
+	}
+	joined := strings.Split(path.Join(base, strings.Join(escaped, pathParamMarker)), pathParamMarker)
+	if len(joined) != len(literals) {
+		return nil, fmt.Errorf("path parameter removed by cleaning path")
+	}
+	return joined, nil
+}
+
+func parsePathReference(raw string) (*url.URL, error) {
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse path: %w", err)
+	}
+	if ref.Scheme != "" || ref.Host != "" || ref.RawQuery != "" || ref.ForceQuery || ref.Fragment != "" {
+		return nil, fmt.Errorf("path must be a relative path reference: %v", raw)
+	}
+	return ref, nil
+}
+
+const escapedPathParamMarker = "%00"
+
+func resolveLiterals(u *url.URL, literals []string) ([]string, error) {
+	if strings.Contains(u.Path, pathParamMarker) {
+		return nil, fmt.Errorf("invalid base path: %q", u.Path)
+	}
+	ref, err := parsePathReference(strings.Join(literals, escapedPathParamMarker))
+	if err != nil {
+		return nil, err
+	}
+	if strings.Count(ref.Path, pathParamMarker) != len(literals)-1 {
+		return nil, fmt.Errorf("invalid path: %q", ref.Path)
+	}
+	resolved := strings.Split(u.ResolveReference(ref).EscapedPath(), escapedPathParamMarker)
+	if len(resolved) != len(literals) {
+		return nil, fmt.Errorf("path parameter removed by resolving path")
+	}
+	return resolved, nil
+}
+
+func interleavePath(literals, values []string, escape func(string) string) string {
+	var sb strings.Builder
+	for i, v := range values {
+		sb.WriteString(literals[i])
+		sb.WriteString(escape(v))
+	}
+	sb.WriteString(literals[len(literals)-1])
+	return sb.String()
+}
+
+type bodyGetter interface {
+	getBody() (io.ReadCloser, error)
+}
+
+func rewinder(body io.Reader) (func() (io.ReadCloser, error), error) {
+	switch v := body.(type) {
+	case bodyGetter:
+		return v.getBody, nil
+	case *bytes.Buffer:
+		buf := v.Bytes()
+		return func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(buf)), nil
+		}, nil
+	}
+	s, ok := body.(io.ReadSeeker)
+	if !ok {
+		return nil, nil
+	}
+	offset, err := s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	return func() (io.ReadCloser, error) {
+		if _, err := s.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(s), nil
+	}, nil
+}
+
 func cloneHeader(h http.Header) http.Header {
 	h2 := make(http.Header, len(h))
 	for k, vv := range h {
@@ -2,6 +2,7 @@ package httpc
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
@@ -9,13 +10,24 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
 type RequestOptions struct {
+	Context context.Context
+
 	Body    io.Reader
 	Header  http.Header
 	Queries url.Values
 
+	PathParams map[string]string
+
+	Timeout time.Duration
+
+	Compression Codec
+
+	Finalizers []func(req *http.Request) error
+
 	EnforceContentLength bool
 }
 
@@ -77,6 +89,47 @@ func WithJSON(data interface{}) RequestOption {
 	}
 }
 
+func WithJSONStream(data interface{}) RequestOption {
+	return func(o *RequestOptions) error {
+		o.setHeaderIfNotExists("Content-Type", "application/json")
+		o.Body = newStreamBody(func(w io.Writer) error {
+			return json.NewEncoder(w).Encode(data)
+		})
+		return nil
+	}
+}
+
+type streamBody struct {
+	write func(w io.Writer) error
+	pr    *io.PipeReader
+}
+
+func newStreamBody(write func(w io.Writer) error) *streamBody {
+	return &streamBody{write: write}
+}
+
+func (b *streamBody) Read(p []byte) (int, error) {
+	if b.pr == nil {
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(b.write(pw))
+		}()
+		b.pr = pr
+	}
+	return b.pr.Read(p)
+}
+
+func (b *streamBody) Close() error {
+	if b.pr == nil {
+		return nil
+	}
+	return b.pr.Close()
+}
+
+func (b *streamBody) getBody() (io.ReadCloser, error) {
+	return newStreamBody(b.write), nil
+}
+
 func WithXML(data interface{}) RequestOption {
 	return func(o *RequestOptions) error {
 		o.setHeaderIfNotExists("Content-Type", `application/xml; charset="UTF-8"`)
@@ -151,6 +204,35 @@ func WithQueries(queries url.Values) RequestOption {
 	}
 }
 
+func WithPathParams(params map[string]string) RequestOption {
+	return func(o *RequestOptions) error {
+		if o.PathParams == nil {
+			o.PathParams = make(map[string]string, len(params))
+		}
+		for k, v := range params {
+			o.PathParams[k] = v
+		}
+		return nil
+	}
+}
+
+// WithTimeout bounds the request until its response body is closed.
+// With a plain http.Client.Do the deadline counts from NewRequest. Do, Retry and RetryTransport
+// instead start it when each attempt is sent and release it as soon as the body is closed.
+func WithTimeout(timeout time.Duration) RequestOption {
+	return func(o *RequestOptions) error {
+		o.Timeout = timeout
+		return nil
+	}
+}
+
+func WithFinalizer(f func(req *http.Request) error) RequestOption {
+	return func(o *RequestOptions) error {
+		o.Finalizers = append(o.Finalizers, f)
+		return nil
+	}
+}
+
 func EnforceContentLength(o *RequestOptions) error {
 	o.EnforceContentLength = true
 	return nil
@@ -9,11 +9,14 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"reflect"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func readAllString(r io.Reader) (string, error) {
@@ -123,6 +126,34 @@ func TestNewRequest(t *testing.T) {
 		}
 	})
 
+	t.Run("WithJSONStream", func(t *testing.T) {
+		expectedJSON := `{"text":"hello from test bot"}` + "\n"
+
+		req, err := NewRequest(context.Background(), http.MethodPost, rawurl,
+			WithJSONStream(map[string]string{"text": "hello from test bot"}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := req.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("unexpected content-type. expected: application/json, got: %v", got)
+		}
+		for i := 0; i < 2; i++ {
+			body := req.Body
+			if i > 0 {
+				body, err = req.GetBody()
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got, err := readAllString(body); got != expectedJSON {
+				t.Errorf("unexpected json body. expected: %v, got: %v", expectedJSON, got)
+			} else if err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+
 	t.Run("WithXML", func(t *testing.T) {
 		type User struct {
 			XMLName xml.Name
@@ -309,6 +340,72 @@ func TestNewRequest(t *testing.T) {
 		})
 	})
 
+	t.Run("GetBody", func(t *testing.T) {
+		expected := "Rewindable"
+		tf, err := ioutil.TempFile("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(tf.Name())
+		defer tf.Close()
+		tf.WriteString(expected)
+		tf.Seek(0, io.SeekStart)
+
+		for _, opt := range []RequestOption{
+			WithForm(url.Values{"q": {expected}}),
+			WithJSON(expected),
+			WithXML(expected),
+			WithBody(tf),
+		} {
+			req, err := NewRequest(context.Background(), http.MethodPost, rawurl, opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if req.GetBody == nil {
+				t.Fatal("GetBody is not populated")
+			}
+			first, err := readAllString(req.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, err := readAllString(body); got != first {
+				t.Errorf("unexpected rewound body. expected: %v, got: %v", first, got)
+			} else if err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+	t.Run("CloseFileBody", func(t *testing.T) {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer s.Close()
+
+		tf, err := ioutil.TempFile("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(tf.Name())
+		defer tf.Close()
+		tf.WriteString("body")
+		tf.Seek(0, io.SeekStart)
+
+		req, err := NewRequest(context.Background(), http.MethodPost, s.URL, WithBody(tf))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if _, err := tf.Seek(0, io.SeekStart); err == nil {
+			t.Errorf("file body is not closed by the transport")
+		}
+	})
+
 	t.Run("OverridePattern", func(t *testing.T) {
 		t.Run("Query", func(t *testing.T) {
 			expected := ""
@@ -423,6 +520,18 @@ func TestNewRequest(t *testing.T) {
 		}
 	})
 
+	t.Run("CantMarshalJSONStream", func(t *testing.T) {
+		req, err := NewRequest(context.Background(), http.MethodPost, rawurl,
+			WithJSONStream(&cantMarshalJSON{}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ioutil.ReadAll(req.Body); err == nil {
+			t.Errorf("accept cant MarshalJSON")
+		}
+	})
+
 	t.Run("CantMarshalXML", func(t *testing.T) {
 		req, err := NewRequest(context.Background(), http.MethodPost, rawurl,
 			WithXML(&cantMarshalXML{}),
@@ -465,3 +574,303 @@ type cantMarshalXML struct{}
 func (*cantMarshalXML) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 	return errors.New("cant")
 }
+
+func TestRequestBuilderPathParams(t *testing.T) {
+	rb, err := NewRequestBuilder("http://api.example/base/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("PositiveCase", func(t *testing.T) {
+		expected := "http://api.example/base/v1/users/a%2Fb%20c/posts/42"
+		req, err := rb.NewRequest(context.Background(), http.MethodGet, "/v1/users/{id}/posts/{postID}",
+			WithPathParams(map[string]string{"id": "a/b c", "postID": "42"}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := req.URL.String(); got != expected {
+			t.Errorf("unexpected url. expected: %v, got: %v", expected, got)
+		}
+		if got := req.URL.Path; got != "/base/v1/users/a/b c/posts/42" {
+			t.Errorf("unexpected path. got: %v", got)
+		}
+	})
+
+	t.Run("Traversal", func(t *testing.T) {
+		rb, err := NewRequestBuilder("http://x.example/v1/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ts := []struct {
+			ID       string
+			Expected string
+		}{
+			{"../x", "http://x.example/v1/users/..%2Fx/posts"},
+			{"a/../../admin", "http://x.example/v1/users/a%2F..%2F..%2Fadmin/posts"},
+		}
+		for _, tc := range ts {
+			req, err := rb.NewRequest(context.Background(), http.MethodGet, "/users/{id}/posts",
+				WithPathParams(map[string]string{"id": tc.ID}),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := req.URL.String(); got != tc.Expected {
+				t.Errorf("unexpected url. expected: %v, got: %v", tc.Expected, got)
+			}
+		}
+	})
+
+	ts := []struct {
+		Name   string
+		Path   string
+		Params map[string]string
+	}{
+		{"Missing", "/v1/users/{id}", nil},
+		{"Removed", "/v1/users/{id}/..", map[string]string{"id": "1"}},
+		{"Unused", "/v1/users/{id}", map[string]string{"id": "1", "postID": "2"}},
+		{"Unclosed", "/v1/users/{id", map[string]string{"id": "1"}},
+		{"DotDot", "/v1/users/{id}", map[string]string{"id": ".."}},
+	}
+	for _, tc := range ts {
+		t.Run(tc.Name, func(t *testing.T) {
+			req, err := rb.NewRequest(context.Background(), http.MethodGet, tc.Path, WithPathParams(tc.Params))
+			if err == nil {
+				t.Errorf("accept invalid path params")
+			}
+			if req != nil {
+				t.Errorf("return invalid request")
+			}
+		})
+	}
+}
+
+func TestRequestBuilderJoinMode(t *testing.T) {
+	ts := []struct {
+		Base     string
+		Path     string
+		Params   map[string]string
+		Expected string
+	}{
+		{"http://s3.example/", "/buckets/a%2Fb/", nil, "http://s3.example/buckets/a%2Fb/"},
+		{"http://api.example/v1/", "users/", nil, "http://api.example/v1/users/"},
+		{"http://api.example/v1/users", "groups", nil, "http://api.example/v1/groups"},
+		{"http://api.example/v1/users/", "../groups/./1", nil, "http://api.example/v1/groups/1"},
+		{"http://api.example/v1/", "", nil, "http://api.example/v1/"},
+		{"http://s3.example/", "buckets/{bucket}/", map[string]string{"bucket": "a/b"}, "http://s3.example/buckets/a%2Fb/"},
+		{"http://api.example/v1/", "users/{id}/posts", map[string]string{"id": "../x"}, "http://api.example/v1/users/..%2Fx/posts"},
+	}
+	for _, tc := range ts {
+		rb, err := NewRequestBuilder(tc.Base, nil, WithJoinMode(JoinResolveReference))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var opts []RequestOption
+		if tc.Params != nil {
+			opts = append(opts, WithPathParams(tc.Params))
+		}
+		req, err := rb.NewRequest(context.Background(), http.MethodGet, tc.Path, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := req.URL.String(); got != tc.Expected {
+			t.Errorf("unexpected url. expected: %v, got: %v", tc.Expected, got)
+		}
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		expected := "http://api.example/v1/users"
+		rb, err := NewRequestBuilder("http://api.example/v1/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := rb.NewRequest(context.Background(), http.MethodGet, "users/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := req.URL.String(); got != expected {
+			t.Errorf("unexpected url. expected: %v, got: %v", expected, got)
+		}
+	})
+	t.Run("RemovedParameter", func(t *testing.T) {
+		rb, err := NewRequestBuilder("http://api.example/", nil, WithJoinMode(JoinResolveReference))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := rb.NewRequest(context.Background(), http.MethodGet, "/v1/{id}/../x", WithPathParams(map[string]string{"id": "1"}))
+		if err == nil {
+			t.Errorf("accept removed path parameter")
+		}
+		if req != nil {
+			t.Errorf("return invalid request")
+		}
+	})
+	t.Run("NotPathReference", func(t *testing.T) {
+		rb, err := NewRequestBuilder("http://api.example/v1/", nil, WithJoinMode(JoinResolveReference))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := rb.NewRequest(context.Background(), http.MethodGet, "http://other.example/")
+		if err == nil {
+			t.Errorf("accept absolute url")
+		}
+		if req != nil {
+			t.Errorf("return invalid request")
+		}
+	})
+}
+
+func TestRequestBuilderDefaults(t *testing.T) {
+	parent, err := NewRequestBuilder("http://api.example/", nil,
+		WithDefaultOptions(AddQuery("api_key", "secret"), EnforceContentLength),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := parent.With(SetHeaderField("X-Tenant", "acme"))
+
+	req, err := child.NewRequest(context.Background(), http.MethodPost, "/v1/users",
+		AddQuery("page", "2"),
+		WithBody(&unreadableSizer{10}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := req.URL.Query().Get("api_key"); got != "secret" {
+		t.Errorf("unexpected api_key param. expected: secret, got: %v", got)
+	}
+	if got := req.URL.Query().Get("page"); got != "2" {
+		t.Errorf("unexpected page param. expected: 2, got: %v", got)
+	}
+	if got := req.Header.Get("X-Tenant"); got != "acme" {
+		t.Errorf("unexpected X-Tenant header. expected: acme, got: %v", got)
+	}
+	if got := req.ContentLength; got != 10 {
+		t.Errorf("unexpected ContentLength. expected: 10, got: %v", got)
+	}
+
+	req, err = parent.NewRequest(context.Background(), http.MethodGet, "/v1/users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := req.Header.Get("X-Tenant"); got != "" {
+		t.Errorf("parent builder is modified. X-Tenant: %v", got)
+	}
+
+	t.Run("Override", func(t *testing.T) {
+		req, err := child.NewRequest(context.Background(), http.MethodGet, "/v1/users",
+			SetHeaderField("X-Tenant", "other"),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := req.Header.Get("X-Tenant"); got != "other" {
+			t.Errorf("unexpected X-Tenant header. expected: other, got: %v", got)
+		}
+	})
+}
+
+func TestWithTimeout(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	t.Run("Timeout", func(t *testing.T) {
+		req, err := NewRequest(context.Background(), http.MethodGet, s.URL+"/slow", WithTimeout(50*time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := Do(http.DefaultClient, req)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("unexpected error. expected: %v, got: %v", context.DeadlineExceeded, err)
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+	})
+	t.Run("PlainClient", func(t *testing.T) {
+		req, err := NewRequest(context.Background(), http.MethodGet, s.URL+"/slow", WithTimeout(50*time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("unexpected error. expected: %v, got: %v", context.DeadlineExceeded, err)
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+	})
+	t.Run("CancelOnClose", func(t *testing.T) {
+		req, err := NewRequest(context.Background(), http.MethodGet, s.URL, WithTimeout(time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := Do(http.DefaultClient, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := resp.Request.Context().Err(); err != nil {
+			t.Errorf("context is released before body is closed: %v", err)
+		}
+		if got, err := readAllString(resp.Body); got != "ok" {
+			t.Errorf("unexpected response body. expected: ok, got: %v", got)
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if err := resp.Request.Context().Err(); err != context.Canceled {
+			t.Errorf("context is not released after body is closed: %v", err)
+		}
+	})
+	t.Run("StartOnSend", func(t *testing.T) {
+		req, err := NewRequest(context.Background(), http.MethodGet, s.URL, WithTimeout(20*time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+		resp, err := Do(http.DefaultClient, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	})
+	t.Run("Retry", func(t *testing.T) {
+		var attempts int32
+		mux := http.NewServeMux()
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&attempts, 1) == 1 {
+				<-r.Context().Done()
+				return
+			}
+			w.Write([]byte("ok"))
+		})
+		s := httptest.NewServer(mux)
+		defer s.Close()
+
+		req, err := NewRequest(context.Background(), http.MethodGet, s.URL, WithTimeout(50*time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := Retry(http.DefaultClient, req, WithClock(&fakeClock{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := readAllString(resp.Body); got != "ok" {
+			t.Errorf("unexpected response body. expected: ok, got: %v", got)
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if err := resp.Request.Context().Err(); err != context.Canceled {
+			t.Errorf("context is not released after body is closed: %v", err)
+		}
+	})
+}
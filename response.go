@@ -0,0 +1,106 @@
+package httpc
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+var DefaultMaxResponseBodySize int64 = 10 << 20
+var StatusErrorBodySize = 512
+
+type decodeOptions struct {
+	MaxBodySize int64
+}
+
+type DecodeOption func(*decodeOptions)
+
+func WithMaxBodySize(size int64) DecodeOption {
+	return func(o *decodeOptions) {
+		o.MaxBodySize = size
+	}
+}
+
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	if len(e.Body) == 0 {
+		return fmt.Sprintf("unexpected status: %v", e.Status)
+	}
+	return fmt.Sprintf("unexpected status: %v: %s", e.Status, e.Body)
+}
+
+func CheckStatus(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, int64(StatusErrorBodySize)))
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     resp.Header,
+		Body:       b,
+	}
+}
+
+func DecodeJSON(resp *http.Response, v interface{}, opts ...DecodeOption) error {
+	return decodeResponse(resp, isJSONMediaType, json.Unmarshal, v, opts)
+}
+
+func DecodeXML(resp *http.Response, v interface{}, opts ...DecodeOption) error {
+	return decodeResponse(resp, isXMLMediaType, xml.Unmarshal, v, opts)
+}
+
+func decodeResponse(resp *http.Response, accept func(string) bool, unmarshal func([]byte, interface{}) error, v interface{}, opts []DecodeOption) error {
+	if resp == nil {
+		return fmt.Errorf("missing response")
+	}
+	options := &decodeOptions{
+		MaxBodySize: DefaultMaxResponseBodySize,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	defer func() {
+		io.CopyN(ioutil.Discard, resp.Body, options.MaxBodySize)
+		resp.Body.Close()
+	}()
+
+	if err := CheckStatus(resp); err != nil {
+		return err
+	}
+	ct := resp.Header.Get("Content-Type")
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil || !accept(mt) {
+		return fmt.Errorf("unexpected content-type: %q", ct)
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, options.MaxBodySize+1))
+	if err != nil {
+		return fmt.Errorf("read response body: %w", err)
+	}
+	if int64(len(b)) > options.MaxBodySize {
+		return fmt.Errorf("response body too large: exceeds %d bytes", options.MaxBodySize)
+	}
+	if err := unmarshal(b, v); err != nil {
+		return fmt.Errorf("decode response body: %w", err)
+	}
+	return nil
+}
+
+func isJSONMediaType(mt string) bool {
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+func isXMLMediaType(mt string) bool {
+	return mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml")
+}
@@ -0,0 +1,105 @@
+package httpc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestResponse(status int, contentType, body string) *http.Response {
+	w := httptest.NewRecorder()
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.WriteHeader(status)
+	fmt.Fprint(w, body)
+	return w.Result()
+}
+
+type closeRecorder struct {
+	*strings.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestDecodeJSON(t *testing.T) {
+	type message struct {
+		Text string `json:"text"`
+	}
+
+	t.Run("PositiveCase", func(t *testing.T) {
+		resp := newTestResponse(http.StatusOK, "application/json; charset=utf-8", `{"text":"hello"}`)
+		body := &closeRecorder{Reader: strings.NewReader(`{"text":"hello"}`)}
+		resp.Body = body
+		var got message
+		if err := DecodeJSON(resp, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Text != "hello" {
+			t.Errorf("unexpected text. expected: hello, got: %v", got.Text)
+		}
+		if !body.closed {
+			t.Error("response body is not closed")
+		}
+	})
+	t.Run("StatusError", func(t *testing.T) {
+		resp := newTestResponse(http.StatusNotFound, "application/json", strings.Repeat("x", StatusErrorBodySize*2))
+		var got message
+		err := DecodeJSON(resp, &got)
+		var se *StatusError
+		if !errors.As(err, &se) {
+			t.Fatalf("unexpected error. expected: *StatusError, got: %v", err)
+		}
+		if se.StatusCode != http.StatusNotFound {
+			t.Errorf("unexpected status code. expected: %v, got: %v", http.StatusNotFound, se.StatusCode)
+		}
+		if got := len(se.Body); got != StatusErrorBodySize {
+			t.Errorf("unexpected body size. expected: %v, got: %v", StatusErrorBodySize, got)
+		}
+	})
+	t.Run("UnexpectedContentType", func(t *testing.T) {
+		resp := newTestResponse(http.StatusOK, "text/html", `<html></html>`)
+		var got message
+		if err := DecodeJSON(resp, &got); err == nil {
+			t.Error("accept unexpected content-type")
+		}
+	})
+	t.Run("TooLarge", func(t *testing.T) {
+		resp := newTestResponse(http.StatusOK, "application/problem+json", `{"text":"hello"}`)
+		var got message
+		if err := DecodeJSON(resp, &got, WithMaxBodySize(4)); err == nil {
+			t.Error("accept too large body")
+		}
+	})
+}
+
+func TestDecodeXML(t *testing.T) {
+	type message struct {
+		Text string
+	}
+
+	t.Run("PositiveCase", func(t *testing.T) {
+		resp := newTestResponse(http.StatusOK, "text/xml", `<message><Text>hello</Text></message>`)
+		var got message
+		if err := DecodeXML(resp, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Text != "hello" {
+			t.Errorf("unexpected text. expected: hello, got: %v", got.Text)
+		}
+	})
+	t.Run("UnexpectedContentType", func(t *testing.T) {
+		resp := newTestResponse(http.StatusOK, "application/json", `{}`)
+		var got message
+		if err := DecodeXML(resp, &got); err == nil {
+			t.Error("accept unexpected content-type")
+		}
+	})
+}
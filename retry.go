@@ -1,6 +1,8 @@
 package httpc
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -10,7 +12,55 @@ import (
 )
 
 var TimeNow func() time.Time = time.Now
-var TimeSleep func(d time.Duration) = time.Sleep
+
+// TimeSleep overrides the sleep of the default Clock when set. Unlike the default,
+// an override cannot be interrupted and may outlive a cancelled ctx by up to d.
+var TimeSleep func(d time.Duration)
+
+type Clock interface {
+	Now() time.Time
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+type globalClock struct{}
+
+func (globalClock) Now() time.Time {
+	return TimeNow()
+}
+
+func (globalClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if d <= 0 {
+		return nil
+	}
+	if sleep := TimeSleep; sleep != nil {
+		return sleepFunc(ctx, sleep, d)
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func sleepFunc(ctx context.Context, sleep func(time.Duration), d time.Duration) error {
+	done := make(chan struct{})
+	go func() {
+		sleep(d)
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
 func Retry(client *http.Client, req *http.Request, opts ...RetryOption) (*http.Response, error) {
 	if client == nil {
@@ -19,42 +69,170 @@ func Retry(client *http.Client, req *http.Request, opts ...RetryOption) (*http.R
 	if req == nil {
 		return nil, fmt.Errorf("missing request")
 	}
-	options := &retryOptions{
-		MaxAttempt:      DefaultMaxAttempt,
-		BackoffStrategy: DefaultBackoffStrategy,
-	}
-	for _, opt := range opts {
-		opt(options)
+	do := func(r *http.Request) (*http.Response, error) {
+		r.Close = false
+		return client.Do(r)
 	}
+	return retry(do, req, newRetryOptions(opts...))
+}
 
-	attempt := uint(0)
+func retry(do func(*http.Request) (*http.Response, error), req *http.Request, options *retryOptions) (resp *http.Response, err error) {
+	req, reqTimeout := detachTimeout(req)
+	if c, ok := req.Body.(readSeekCloser); ok && req.GetBody != nil {
+		// The transport closes the body after every attempt, but a rewound seeker shares
+		// the underlying file, so it is closed only once retrying is over.
+		defer c.Close()
+		r := *req
+		r.Body = ioutil.NopCloser(c)
+		req = &r
+	}
+	ctx := req.Context()
+	var attempts []RetryAttempt
+	defer func() {
+		for _, hook := range options.DoneHooks {
+			hook(attempts, resp, err)
+		}
+	}()
+	r := req
+	backoff := newBackoffSession(options.BackoffStrategy)
+	began := options.Clock.Now()
 	for {
-		req.Close = false
-		resp, err := client.Do(req)
-		if err != nil {
-			if !isTimeout(err) && !isTemporary(err) {
-				return nil, err
+		start := options.Clock.Now()
+		timeout := options.AttemptTimeout
+		if reqTimeout > 0 && (timeout <= 0 || reqTimeout < timeout) {
+			timeout = reqTimeout
+		}
+		if options.MaxElapsedTime > 0 {
+			remaining := options.MaxElapsedTime - start.Sub(began)
+			if len(attempts) > 0 && remaining <= 0 {
+				return nil, &RetryError{Attempts: attempts, Err: ErrMaxElapsedTimeExceeded}
+			}
+			if timeout <= 0 || remaining < timeout {
+				timeout = remaining
 			}
-		} else {
-			if !isTemporaryStatus(resp.StatusCode) {
-				return resp, nil
+		}
+		resp, err := doAttempt(do, r, timeout)
+		attempt := uint(len(attempts) + 1)
+		attempts = append(attempts, newRetryAttempt(start, resp, err))
+		if !options.RetryPolicy.ShouldRetry(attempt, r, resp, err) {
+			if err != nil {
+				return nil, err
 			}
-			io.Copy(ioutil.Discard, resp.Body)
-			resp.Body.Close()
+			return resp, nil
 		}
-		attempt++
 		if attempt >= options.MaxAttempt {
-			return nil, fmt.Errorf("max attempt exceeded")
+			rerr := &RetryError{Attempts: attempts, Err: err}
+			if err == nil && options.KeepLastResponse {
+				rerr.Response = resp
+			} else if err == nil {
+				discardBody(resp)
+			}
+			return nil, rerr
+		}
+		if err == nil {
+			discardBody(resp)
 		}
+		next, rerr := rewindBody(req)
+		if rerr != nil {
+			return nil, rerr
+		}
+		r = next
+		d, ok := time.Duration(0), false
 		if err == nil && len(resp.Header.Get("Retry-After")) > 0 {
-			d, err := parseRetryAfter(resp.Header.Get("Retry-After"))
-			if err == nil {
-				TimeSleep(d)
-				continue
+			ra, perr := parseRetryAfter(resp.Header.Get("Retry-After"), options.Clock.Now())
+			d, ok = ra, perr == nil
+		}
+		if !ok {
+			d = backoff.Backoff(attempt)
+		}
+		if options.MaxElapsedTime > 0 {
+			remaining := options.MaxElapsedTime - options.Clock.Now().Sub(began)
+			reserve := options.MaxElapsedTime / maxElapsedTimeReserve
+			if (ok && d >= remaining) || (!ok && remaining <= reserve) {
+				return nil, &RetryError{Attempts: attempts, Err: ErrMaxElapsedTimeExceeded}
+			}
+			if !ok && d > remaining-reserve {
+				d = remaining - reserve
 			}
 		}
-		TimeSleep(options.BackoffStrategy.Backoff(attempt))
+		attempts[len(attempts)-1].Delay = d
+		for _, hook := range options.RetryHooks {
+			hook(attempt, resp, err, d)
+		}
+		if err := options.Clock.Sleep(ctx, d); err != nil {
+			return nil, &RetryError{Attempts: attempts, Err: err}
+		}
+	}
+}
+
+type readSeekCloser interface {
+	io.ReadSeeker
+	io.Closer
+}
+
+func doAttempt(do func(*http.Request) (*http.Response, error), req *http.Request, timeout time.Duration) (*http.Response, error) {
+	if timeout <= 0 {
+		return do(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	resp, err := do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return resp, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func discardBody(resp *http.Response) {
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+var ErrMaxElapsedTimeExceeded = errors.New("max elapsed time exceeded")
+
+// A trimmed backoff leaves at least MaxElapsedTime/maxElapsedTimeReserve for the next attempt.
+const maxElapsedTimeReserve = 10
+
+var ErrBodyNotRewindable = errors.New("request body is not rewindable")
+
+type RewindBodyError struct {
+	Err error
+}
+
+func (e *RewindBodyError) Error() string {
+	return "rewind request body: " + e.Err.Error()
+}
+
+func (e *RewindBodyError) Unwrap() error {
+	return e.Err
+}
+
+func rewindBody(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, &RewindBodyError{Err: ErrBodyNotRewindable}
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, &RewindBodyError{Err: err}
 	}
+	r := *req
+	r.Body = body
+	return &r, nil
 }
 
 type temporary interface {
@@ -75,23 +253,9 @@ func isTimeout(err error) bool {
 	return ok && to.Timeout()
 }
 
-func isTemporaryStatus(status int) bool {
-	switch status {
-	case
-		http.StatusInternalServerError,
-		http.StatusBadGateway,
-		http.StatusServiceUnavailable,
-		http.StatusGatewayTimeout,
-		http.StatusRequestTimeout,
-		http.StatusTooManyRequests:
-		return true
-	}
-	return false
-}
-
-func parseRetryAfter(ra string) (time.Duration, error) {
+func parseRetryAfter(ra string, now time.Time) (time.Duration, error) {
 	if d, err := http.ParseTime(ra); err == nil {
-		return d.Sub(TimeNow()), nil
+		return d.Sub(now), nil
 	}
 	if s, err := strconv.ParseUint(ra, 10, 32); err == nil {
 		return time.Duration(s) * time.Second, nil
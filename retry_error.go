@@ -0,0 +1,46 @@
+package httpc
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type RetryAttempt struct {
+	StatusCode int
+	Err        error
+	Start      time.Time
+	Delay      time.Duration
+}
+
+func newRetryAttempt(start time.Time, resp *http.Response, err error) RetryAttempt {
+	a := RetryAttempt{Err: err, Start: start}
+	if resp != nil {
+		a.StatusCode = resp.StatusCode
+	}
+	return a
+}
+
+type RetryError struct {
+	Attempts []RetryAttempt
+	Err      error
+	Response *http.Response
+}
+
+func (e *RetryError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("retry gave up after %d attempts: %v", len(e.Attempts), e.Err)
+	}
+	return fmt.Sprintf("retry gave up after %d attempts: last status %d", len(e.Attempts), e.StatusCode())
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+func (e *RetryError) StatusCode() int {
+	if len(e.Attempts) == 0 {
+		return 0
+	}
+	return e.Attempts[len(e.Attempts)-1].StatusCode
+}
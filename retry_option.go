@@ -1,8 +1,23 @@
 package httpc
 
+import (
+	"net/http"
+	"time"
+)
+
 type retryOptions struct {
 	MaxAttempt      uint
 	BackoffStrategy BackoffStrategy
+	Clock           Clock
+	RetryPolicy     RetryPolicy
+
+	MaxElapsedTime time.Duration
+	AttemptTimeout time.Duration
+
+	KeepLastResponse bool
+
+	RetryHooks []RetryHook
+	DoneHooks  []DoneHook
 }
 
 var DefaultMaxAttempt uint = 15
@@ -10,14 +25,71 @@ var DefaultBackoffStrategy BackoffStrategy = TruncatedExponentialBackoff(6)
 
 type RetryOption func(*retryOptions)
 
+type RetryHook func(attempt uint, resp *http.Response, err error, delay time.Duration)
+
+type DoneHook func(attempts []RetryAttempt, resp *http.Response, err error)
+
+func newRetryOptions(opts ...RetryOption) *retryOptions {
+	options := &retryOptions{
+		MaxAttempt:      DefaultMaxAttempt,
+		BackoffStrategy: DefaultBackoffStrategy,
+		Clock:           globalClock{},
+		RetryPolicy:     DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
 func WithMaxAttempt(maxAttempt uint) RetryOption {
 	return func(o *retryOptions) {
 		o.MaxAttempt = maxAttempt
 	}
 }
 
+func WithMaxElapsedTime(d time.Duration) RetryOption {
+	return func(o *retryOptions) {
+		o.MaxElapsedTime = d
+	}
+}
+
+func WithAttemptTimeout(d time.Duration) RetryOption {
+	return func(o *retryOptions) {
+		o.AttemptTimeout = d
+	}
+}
+
 func WithBackoffStrategy(strategy BackoffStrategy) RetryOption {
 	return func(o *retryOptions) {
 		o.BackoffStrategy = strategy
 	}
 }
+
+func WithClock(clock Clock) RetryOption {
+	return func(o *retryOptions) {
+		o.Clock = clock
+	}
+}
+
+func WithRetryPolicy(policy RetryPolicy) RetryOption {
+	return func(o *retryOptions) {
+		o.RetryPolicy = policy
+	}
+}
+
+func KeepLastResponse(o *retryOptions) {
+	o.KeepLastResponse = true
+}
+
+func OnRetry(hook RetryHook) RetryOption {
+	return func(o *retryOptions) {
+		o.RetryHooks = append(o.RetryHooks, hook)
+	}
+}
+
+func OnDone(hook DoneHook) RetryOption {
+	return func(o *retryOptions) {
+		o.DoneHooks = append(o.DoneHooks, hook)
+	}
+}
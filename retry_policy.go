@@ -0,0 +1,105 @@
+package httpc
+
+import (
+	"net/http"
+)
+
+type RetryPolicy interface {
+	ShouldRetry(attempt uint, req *http.Request, resp *http.Response, err error) bool
+}
+
+type RetryPolicyFunc func(attempt uint, req *http.Request, resp *http.Response, err error) bool
+
+func (f RetryPolicyFunc) ShouldRetry(attempt uint, req *http.Request, resp *http.Response, err error) bool {
+	return f(attempt, req, resp, err)
+}
+
+var DefaultRetryPolicy RetryPolicy = AnyRetryPolicy(
+	RetryOnTemporaryError(),
+	RetryOnStatus(
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+		http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+	),
+)
+
+type temporaryErrorPolicy struct{}
+
+func RetryOnTemporaryError() RetryPolicy {
+	return &temporaryErrorPolicy{}
+}
+
+func (*temporaryErrorPolicy) ShouldRetry(attempt uint, req *http.Request, resp *http.Response, err error) bool {
+	return err != nil && (isTimeout(err) || isTemporary(err))
+}
+
+type statusPolicy struct {
+	codes map[int]struct{}
+}
+
+func RetryOnStatus(codes ...int) RetryPolicy {
+	m := make(map[int]struct{}, len(codes))
+	for _, code := range codes {
+		m[code] = struct{}{}
+	}
+	return &statusPolicy{m}
+}
+
+func (p *statusPolicy) ShouldRetry(attempt uint, req *http.Request, resp *http.Response, err error) bool {
+	if err != nil || resp == nil {
+		return false
+	}
+	_, ok := p.codes[resp.StatusCode]
+	return ok
+}
+
+type anyPolicy struct {
+	policies []RetryPolicy
+}
+
+func AnyRetryPolicy(policies ...RetryPolicy) RetryPolicy {
+	return &anyPolicy{policies}
+}
+
+func (p *anyPolicy) ShouldRetry(attempt uint, req *http.Request, resp *http.Response, err error) bool {
+	for _, policy := range p.policies {
+		if policy.ShouldRetry(attempt, req, resp, err) {
+			return true
+		}
+	}
+	return false
+}
+
+type idempotentPolicy struct {
+	policy RetryPolicy
+}
+
+func IdempotentOnly(policy RetryPolicy) RetryPolicy {
+	return &idempotentPolicy{policy}
+}
+
+func (p *idempotentPolicy) ShouldRetry(attempt uint, req *http.Request, resp *http.Response, err error) bool {
+	return isIdempotent(req) && p.policy.ShouldRetry(attempt, req, resp, err)
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case
+		"",
+		http.MethodGet,
+		http.MethodHead,
+		http.MethodOptions,
+		http.MethodTrace,
+		http.MethodPut,
+		http.MethodDelete:
+		return true
+	}
+	if _, ok := req.Header["Idempotency-Key"]; ok {
+		return true
+	}
+	_, ok := req.Header["X-Idempotency-Key"]
+	return ok
+}
@@ -1,9 +1,17 @@
 package httpc
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -155,6 +163,497 @@ func TestRetry(t *testing.T) {
 			resp.Body.Close()
 		}
 	})
+	t.Run("RewindBody", func(t *testing.T) {
+		expected := `{"message":"hello"}` + "\n"
+		var bodies []string
+		mux := http.NewServeMux()
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			b, _ := ioutil.ReadAll(r.Body)
+			bodies = append(bodies, string(b))
+			if len(bodies) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+		s := httptest.NewServer(mux)
+		defer s.Close()
+
+		req, err := NewRequest(context.Background(), http.MethodPost, s.URL,
+			WithJSON(map[string]string{"message": "hello"}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := Retry(http.DefaultClient, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if len(bodies) != 3 {
+			t.Fatalf("unexpected attempts. expected: 3, got: %v", len(bodies))
+		}
+		for _, got := range bodies {
+			if got != expected {
+				t.Errorf("unexpected request body. expected: %v, got: %v", expected, got)
+			}
+		}
+	})
+	t.Run("RewindFileBody", func(t *testing.T) {
+		expected := "file body"
+		var bodies []string
+		mux := http.NewServeMux()
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			b, _ := ioutil.ReadAll(r.Body)
+			bodies = append(bodies, string(b))
+			if len(bodies) < 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+		s := httptest.NewServer(mux)
+		defer s.Close()
+
+		tf, err := ioutil.TempFile("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(tf.Name())
+		defer tf.Close()
+		tf.WriteString(expected)
+		tf.Seek(0, io.SeekStart)
+
+		req, err := NewRequest(context.Background(), http.MethodPost, s.URL, WithBody(tf))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := Retry(http.DefaultClient, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if len(bodies) != 2 {
+			t.Fatalf("unexpected attempts. expected: 2, got: %v", len(bodies))
+		}
+		if _, err := tf.Seek(0, io.SeekStart); err == nil {
+			t.Errorf("file body is not closed after retrying")
+		}
+		for _, got := range bodies {
+			if got != expected {
+				t.Errorf("unexpected request body. expected: %v, got: %v", expected, got)
+			}
+		}
+	})
+	t.Run("NotRewindableBody", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, s.URL, ioutil.NopCloser(strings.NewReader("body")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := Retry(
+			&http.Client{Transport: &statusTransport{http.StatusServiceUnavailable, 1}},
+			req,
+		)
+		if resp != nil {
+			t.Errorf("return invalid response")
+		}
+		var re *RewindBodyError
+		if !errors.As(err, &re) {
+			t.Fatalf("unexpected error. expected: *RewindBodyError, got: %v", err)
+		}
+		if !errors.Is(err, ErrBodyNotRewindable) {
+			t.Errorf("unexpected error. expected: %v, got: %v", ErrBodyNotRewindable, err)
+		}
+	})
+	t.Run("WithClock", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clock := &fakeClock{now: time.Date(2015, 10, 21, 7, 27, 0, 0, time.UTC)}
+		resp, err := Retry(
+			&http.Client{Transport: &retryAfterTransport{"Wed, 21 Oct 2015 07:28:00 GMT", 1}},
+			req,
+			WithBackoffStrategy(ConstantBackoff(time.Second)),
+			WithClock(clock),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		expected := []time.Duration{time.Minute}
+		if !reflect.DeepEqual(clock.slept, expected) {
+			t.Errorf("unexpected sleeps. expected: %v, got: %v", expected, clock.slept)
+		}
+	})
+	t.Run("CancelDuringBackoff", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clock := &fakeClock{sleep: func(ctx context.Context, d time.Duration) error {
+			cancel()
+			<-ctx.Done()
+			return ctx.Err()
+		}}
+		resp, err := Retry(
+			&http.Client{Transport: &statusTransport{http.StatusServiceUnavailable, 1}},
+			req,
+			WithClock(clock),
+		)
+		if resp != nil {
+			t.Errorf("return invalid response")
+		}
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("unexpected error. expected: %v, got: %v", context.Canceled, err)
+		}
+	})
+	t.Run("CancelDuringDefaultSleep", func(t *testing.T) {
+		TimeSleep = nil
+		defer func() { TimeSleep = func(d time.Duration) {} }()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.AfterFunc(20*time.Millisecond, cancel)
+		start := time.Now()
+		resp, err := Retry(
+			&http.Client{Transport: &statusTransport{http.StatusServiceUnavailable, 1}},
+			req,
+			WithBackoffStrategy(ConstantBackoff(time.Hour)),
+		)
+		if resp != nil {
+			t.Errorf("return invalid response")
+		}
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("unexpected error. expected: %v, got: %v", context.Canceled, err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("backoff is not interrupted. elapsed: %v", elapsed)
+		}
+	})
+	t.Run("RetryPolicy", func(t *testing.T) {
+		ts := []struct {
+			Method     string
+			Transport  http.RoundTripper
+			Policy     RetryPolicy
+			StatusCode int
+		}{
+			{
+				Method:     http.MethodGet,
+				Transport:  &statusTransport{http.StatusConflict, 1},
+				Policy:     DefaultRetryPolicy,
+				StatusCode: http.StatusConflict,
+			},
+			{
+				Method:     http.MethodPost,
+				Transport:  &statusTransport{http.StatusConflict, 1},
+				Policy:     AnyRetryPolicy(DefaultRetryPolicy, RetryOnStatus(http.StatusConflict)),
+				StatusCode: http.StatusOK,
+			},
+			{
+				Method:     http.MethodPost,
+				Transport:  &statusTransport{http.StatusServiceUnavailable, 1},
+				Policy:     IdempotentOnly(DefaultRetryPolicy),
+				StatusCode: http.StatusServiceUnavailable,
+			},
+			{
+				Method:     http.MethodPut,
+				Transport:  &statusTransport{http.StatusServiceUnavailable, 1},
+				Policy:     IdempotentOnly(DefaultRetryPolicy),
+				StatusCode: http.StatusOK,
+			},
+			{
+				Method:    http.MethodGet,
+				Transport: &statusTransport{http.StatusServiceUnavailable, 3},
+				Policy: RetryPolicyFunc(func(attempt uint, req *http.Request, resp *http.Response, err error) bool {
+					return attempt < 2 && resp.StatusCode == http.StatusServiceUnavailable
+				}),
+				StatusCode: http.StatusServiceUnavailable,
+			},
+		}
+
+		for _, tc := range ts {
+			req, err := http.NewRequest(tc.Method, s.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := Retry(&http.Client{Transport: tc.Transport}, req, WithRetryPolicy(tc.Policy))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resp.StatusCode; got != tc.StatusCode {
+				t.Errorf("unexpected status code. expected: %v, got: %v", tc.StatusCode, got)
+			}
+			resp.Body.Close()
+		}
+	})
+	t.Run("RetryError", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Run("Error", func(t *testing.T) {
+			resp, err := Retry(
+				&http.Client{Transport: &errorTransport{&timeoutError{}, 3}},
+				req,
+				WithMaxAttempt(3),
+				WithBackoffStrategy(ConstantBackoff(time.Second)),
+			)
+			if resp != nil {
+				t.Errorf("return invalid response")
+			}
+			var re *RetryError
+			if !errors.As(err, &re) {
+				t.Fatalf("unexpected error. expected: *RetryError, got: %v", err)
+			}
+			var te *timeoutError
+			if !errors.As(err, &te) {
+				t.Errorf("last error is not wrapped: %v", err)
+			}
+			if got := len(re.Attempts); got != 3 {
+				t.Fatalf("unexpected attempts. expected: 3, got: %v", got)
+			}
+			for i, a := range re.Attempts {
+				expected := time.Second
+				if i == len(re.Attempts)-1 {
+					expected = 0
+				}
+				if a.Delay != expected {
+					t.Errorf("unexpected delay. expected: %v, got: %v", expected, a.Delay)
+				}
+			}
+		})
+		t.Run("NoAttempts", func(t *testing.T) {
+			re := &RetryError{Err: ErrMaxElapsedTimeExceeded}
+			if got := re.StatusCode(); got != 0 {
+				t.Errorf("unexpected status code. expected: 0, got: %v", got)
+			}
+			if got := re.Error(); got == "" {
+				t.Errorf("empty error message")
+			}
+		})
+		t.Run("KeepLastResponse", func(t *testing.T) {
+			resp, err := Retry(
+				&http.Client{Transport: &statusTransport{http.StatusServiceUnavailable, 2}},
+				req,
+				WithMaxAttempt(2),
+				KeepLastResponse,
+			)
+			if resp != nil {
+				t.Errorf("return invalid response")
+			}
+			var re *RetryError
+			if !errors.As(err, &re) {
+				t.Fatalf("unexpected error. expected: *RetryError, got: %v", err)
+			}
+			if got := re.StatusCode(); got != http.StatusServiceUnavailable {
+				t.Errorf("unexpected status code. expected: %v, got: %v", http.StatusServiceUnavailable, got)
+			}
+			if re.Response == nil {
+				t.Fatal("last response is not kept")
+			}
+			if _, err := ioutil.ReadAll(re.Response.Body); err != nil {
+				t.Errorf("last response body can't read: %v", err)
+			}
+			re.Response.Body.Close()
+		})
+	})
+	t.Run("Hooks", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var retried []uint
+		var done [][]RetryAttempt
+		resp, err := Retry(
+			&http.Client{Transport: &statusTransport{http.StatusBadGateway, 2}},
+			req,
+			WithBackoffStrategy(ConstantBackoff(time.Second)),
+			OnRetry(func(attempt uint, resp *http.Response, err error, delay time.Duration) {
+				if got := resp.StatusCode; got != http.StatusBadGateway {
+					t.Errorf("unexpected status code. expected: %v, got: %v", http.StatusBadGateway, got)
+				}
+				if delay != time.Second {
+					t.Errorf("unexpected delay. expected: %v, got: %v", time.Second, delay)
+				}
+				retried = append(retried, attempt)
+			}),
+			OnDone(func(attempts []RetryAttempt, resp *http.Response, err error) {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				done = append(done, attempts)
+			}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if expected := []uint{1, 2}; !reflect.DeepEqual(retried, expected) {
+			t.Errorf("unexpected retried attempts. expected: %v, got: %v", expected, retried)
+		}
+		if len(done) != 1 {
+			t.Fatalf("unexpected done calls. expected: 1, got: %v", len(done))
+		}
+		if got := len(done[0]); got != 3 {
+			t.Errorf("unexpected attempts. expected: 3, got: %v", got)
+		}
+	})
+	t.Run("MaxElapsedTime", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Run("TrimBackoff", func(t *testing.T) {
+			clock := &fakeClock{now: time.Now()}
+			resp, err := Retry(
+				&http.Client{Transport: &statusTransport{http.StatusServiceUnavailable, 4}},
+				req,
+				WithBackoffStrategy(ConstantBackoff(4*time.Second)),
+				WithMaxElapsedTime(10*time.Second),
+				WithClock(clock),
+			)
+			if resp != nil {
+				t.Errorf("return invalid response")
+			}
+			if !errors.Is(err, ErrMaxElapsedTimeExceeded) {
+				t.Errorf("unexpected error. expected: %v, got: %v", ErrMaxElapsedTimeExceeded, err)
+			}
+			expected := []time.Duration{4 * time.Second, 4 * time.Second, time.Second}
+			if !reflect.DeepEqual(clock.slept, expected) {
+				t.Errorf("unexpected sleeps. expected: %v, got: %v", expected, clock.slept)
+			}
+			var re *RetryError
+			if errors.As(err, &re) && len(re.Attempts) != 4 {
+				t.Errorf("unexpected attempts. expected: 4, got: %v", len(re.Attempts))
+			}
+		})
+		t.Run("FirstAttempt", func(t *testing.T) {
+			var count int32
+			mux := http.NewServeMux()
+			mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&count, 1)
+				w.WriteHeader(http.StatusOK)
+			})
+			s := httptest.NewServer(mux)
+			defer s.Close()
+
+			req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			clock := &fakeClock{now: time.Now(), tick: time.Second}
+			resp, err := Retry(http.DefaultClient, req, WithMaxElapsedTime(time.Second), WithClock(clock))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if got := atomic.LoadInt32(&count); got != 1 {
+				t.Errorf("unexpected attempts. expected: 1, got: %v", got)
+			}
+		})
+		t.Run("CapAttempt", func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+			})
+			s := httptest.NewServer(mux)
+			defer s.Close()
+
+			req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			start := time.Now()
+			resp, err := Retry(http.DefaultClient, req, WithMaxElapsedTime(50*time.Millisecond))
+			if resp != nil {
+				t.Errorf("return invalid response")
+			}
+			if !errors.Is(err, ErrMaxElapsedTimeExceeded) {
+				t.Errorf("unexpected error. expected: %v, got: %v", ErrMaxElapsedTimeExceeded, err)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("attempt is not bounded by max elapsed time. elapsed: %v", elapsed)
+			}
+		})
+		t.Run("RetryAfter", func(t *testing.T) {
+			clock := &fakeClock{now: time.Now()}
+			resp, err := Retry(
+				&http.Client{Transport: &retryAfterTransport{"3000", 1}},
+				req,
+				WithMaxElapsedTime(time.Minute),
+				WithClock(clock),
+			)
+			if resp != nil {
+				t.Errorf("return invalid response")
+			}
+			if !errors.Is(err, ErrMaxElapsedTimeExceeded) {
+				t.Errorf("unexpected error. expected: %v, got: %v", ErrMaxElapsedTimeExceeded, err)
+			}
+			if len(clock.slept) != 0 {
+				t.Errorf("unexpected sleeps: %v", clock.slept)
+			}
+		})
+	})
+	t.Run("AttemptTimeout", func(t *testing.T) {
+		var count int32
+		mux := http.NewServeMux()
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&count, 1) == 1 {
+				<-r.Context().Done()
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "ok")
+		})
+		s := httptest.NewServer(mux)
+		defer s.Close()
+
+		req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := Retry(http.DefaultClient, req, WithAttemptTimeout(100*time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if got, err := readAllString(resp.Body); got != "ok" {
+			t.Errorf("unexpected response body. expected: ok, got: %v", got)
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if got := atomic.LoadInt32(&count); got != 2 {
+			t.Errorf("unexpected attempts. expected: 2, got: %v", got)
+		}
+	})
+}
+
+type fakeClock struct {
+	now   time.Time
+	tick  time.Duration
+	slept []time.Duration
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.now = c.now.Add(c.tick)
+	return c.now
+}
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	c.slept = append(c.slept, d)
+	if c.sleep != nil {
+		return c.sleep(ctx, d)
+	}
+	c.now = c.now.Add(d)
+	return nil
 }
 
 type timeoutError struct{}
@@ -0,0 +1,49 @@
+package httpc
+
+import (
+	"fmt"
+	"net/http"
+)
+
+type retryTransport struct {
+	opts      []RetryOption
+	transport http.RoundTripper
+}
+
+func RetryTransport(transport http.RoundTripper, opts ...RetryOption) http.RoundTripper {
+	return &retryTransport{opts: opts, transport: transport}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt := t.transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return retry(rt.RoundTrip, req, newRetryOptions(t.opts...))
+}
+
+func InjectRetryTransport(client *http.Client, opts ...RetryOption) error {
+	if client == nil {
+		return fmt.Errorf("missing client")
+	}
+	if t := client.Transport; t != nil {
+		if _, ok := t.(*retryTransport); ok {
+			return nil
+		}
+	}
+	client.Transport = &retryTransport{opts: opts, transport: client.Transport}
+	return nil
+}
+
+func RemoveRetryTransport(client *http.Client) error {
+	if client == nil {
+		return fmt.Errorf("missing client")
+	}
+	if client.Transport == nil {
+		return nil
+	}
+	if rt, ok := client.Transport.(*retryTransport); ok {
+		client.Transport = rt.transport
+	}
+	return nil
+}
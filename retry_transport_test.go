@@ -0,0 +1,77 @@
+package httpc
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport(t *testing.T) {
+	send := `{"message":"hello"}` + "\n"
+	count := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		count++
+		if b, err := ioutil.ReadAll(r.Body); err != nil {
+			t.Error("request body can't read")
+		} else if got := string(b); got != send {
+			t.Errorf("unexpected request body. expected: %v, but got: %v", send, got)
+		}
+		if count < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	client := &http.Client{
+		Transport: RetryTransport(nil, WithBackoffStrategy(ConstantBackoff(time.Millisecond))),
+	}
+	req, err := NewRequest(context.Background(), http.MethodPost, s.URL,
+		WithJSON(map[string]string{"message": "hello"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got := resp.StatusCode; got != http.StatusOK {
+		t.Errorf("unexpected status code. expected: %v, got: %v", http.StatusOK, got)
+	}
+	if count != 3 {
+		t.Errorf("unexpected attempts. expected: 3, got: %v", count)
+	}
+}
+
+func TestInjectRetryTransport(t *testing.T) {
+	t.Run("NilClient", func(t *testing.T) {
+		if err := InjectRetryTransport(nil); err == nil {
+			t.Errorf("accept nil client")
+		}
+	})
+	t.Run("PositiveCase", func(t *testing.T) {
+		rt := http.DefaultTransport
+		c := &http.Client{Transport: rt}
+
+		if err := InjectRetryTransport(c); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := c.Transport.(*retryTransport); !ok {
+			t.Errorf("unexpected transport. expected: *retryTransport, but got: %T", c.Transport)
+		}
+		if err := RemoveRetryTransport(c); err != nil {
+			t.Fatal(err)
+		}
+		if got := c.Transport; got != rt {
+			t.Errorf("unexpected transport. expected: %v, but got: %v", rt, got)
+		}
+	})
+}
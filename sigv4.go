@@ -0,0 +1,340 @@
+package httpc
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SigV4Payload int
+
+const (
+	SignedPayload SigV4Payload = iota
+	UnsignedPayload
+	StreamingPayload
+)
+
+const (
+	sigV4Algorithm        = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat       = "20060102T150405Z"
+	sigV4UnsignedPayload  = "UNSIGNED-PAYLOAD"
+	sigV4StreamingPayload = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	sigV4ChunkAlgorithm   = "AWS4-HMAC-SHA256-PAYLOAD"
+	sigV4ChunkSignature   = ";chunk-signature="
+)
+
+var DefaultSigV4ChunkSize = 64 << 10
+
+var emptySHA256 = hex.EncodeToString(sha256.New().Sum(nil))
+
+type SigV4Config struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Region          string
+	Service         string
+
+	Payload       SigV4Payload
+	ChunkSize     int
+	SignedHeaders []string
+	Now           func() time.Time
+}
+
+func WithSigV4(config SigV4Config) RequestOption {
+	return WithFinalizer(func(req *http.Request) error {
+		return signV4(req, config)
+	})
+}
+
+type sigV4Transport struct {
+	config    SigV4Config
+	transport http.RoundTripper
+}
+
+func SigV4Transport(transport http.RoundTripper, config SigV4Config) http.RoundTripper {
+	return &sigV4Transport{config: config, transport: transport}
+}
+
+func (t *sigV4Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt := t.transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	r := req.Clone(req.Context())
+	if err := signV4(r, t.config); err != nil {
+		closeRequestBody(req)
+		return nil, err
+	}
+	return rt.RoundTrip(r)
+}
+
+func signV4(req *http.Request, c SigV4Config) error {
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return fmt.Errorf("missing aws credentials")
+	}
+	if c.Region == "" || c.Service == "" {
+		return fmt.Errorf("missing aws region or service")
+	}
+	now := c.Now
+	if now == nil {
+		now = TimeNow
+	}
+	t := now().UTC()
+	amzDate := t.Format(sigV4TimeFormat)
+	scope := strings.Join([]string{t.Format("20060102"), c.Region, c.Service, "aws4_request"}, "/")
+
+	hasBody := req.Body != nil && req.Body != http.NoBody
+	var payloadHash string
+	var chunks *chunkSigner
+	switch c.Payload {
+	case SignedPayload:
+		h, err := hashRequestBody(req)
+		if err != nil {
+			return err
+		}
+		payloadHash = h
+	case UnsignedPayload:
+		payloadHash = sigV4UnsignedPayload
+	case StreamingPayload:
+		if hasBody && req.ContentLength <= 0 {
+			return fmt.Errorf("streaming payload requires a known content length")
+		}
+		payloadHash = sigV4StreamingPayload
+		chunks = &chunkSigner{chunkSize: c.ChunkSize}
+		if chunks.chunkSize <= 0 {
+			chunks.chunkSize = DefaultSigV4ChunkSize
+		}
+		req.Header.Set("X-Amz-Decoded-Content-Length", strconv.FormatInt(req.ContentLength, 10))
+		if ce := req.Header.Get("Content-Encoding"); ce != "" {
+			req.Header.Set("Content-Encoding", "aws-chunked,"+ce)
+		} else {
+			req.Header.Set("Content-Encoding", "aws-chunked")
+		}
+		req.ContentLength = chunks.encodedLength(req.ContentLength)
+	default:
+		return fmt.Errorf("unknown sigv4 payload mode: %v", c.Payload)
+	}
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if c.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.SessionToken)
+	}
+
+	canonical, signedHeaders := canonicalV4Request(req, c.Service, c.SignedHeaders, payloadHash)
+	key := sigV4SigningKey(c.SecretAccessKey, t.Format("20060102"), c.Region, c.Service)
+	stringToSign := strings.Join([]string{sigV4Algorithm, amzDate, scope, hexSHA256([]byte(canonical))}, "\n")
+	signature := hex.EncodeToString(hmacSHA256(key, []byte(stringToSign)))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, c.AccessKeyID, scope, signedHeaders, signature))
+
+	if chunks != nil {
+		chunks.key, chunks.amzDate, chunks.scope, chunks.seed = key, amzDate, scope, signature
+		if !hasBody {
+			req.Body = chunks.newBody(http.NoBody)
+			req.GetBody = func() (io.ReadCloser, error) {
+				return chunks.newBody(http.NoBody), nil
+			}
+			return nil
+		}
+		req.Body = chunks.newBody(req.Body)
+		if getBody := req.GetBody; getBody != nil {
+			req.GetBody = func() (io.ReadCloser, error) {
+				body, err := getBody()
+				if err != nil {
+					return nil, err
+				}
+				return chunks.newBody(body), nil
+			}
+		}
+	}
+	return nil
+}
+
+func canonicalV4Request(req *http.Request, service string, extra []string, payloadHash string) (string, string) {
+	signed := make(map[string]bool, len(extra))
+	for _, h := range extra {
+		signed[strings.ToLower(h)] = true
+	}
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	if req.ContentLength > 0 {
+		headers["content-length"] = strconv.FormatInt(req.ContentLength, 10)
+	}
+	for k, vs := range req.Header {
+		name := strings.ToLower(k)
+		switch {
+		case strings.HasPrefix(name, "x-amz-"), name == "content-type", name == "content-md5", name == "content-encoding", signed[name]:
+		default:
+			continue
+		}
+		values := make([]string, len(vs))
+		for i, v := range vs {
+			values[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers[name] = strings.Join(values, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(req.Method)
+	b.WriteByte('\n')
+	b.WriteString(canonicalV4Path(req.URL, service))
+	b.WriteByte('\n')
+	b.WriteString(canonicalV4Query(req.URL))
+	b.WriteByte('\n')
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(headers[name])
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	signedHeaders := strings.Join(names, ";")
+	b.WriteString(signedHeaders)
+	b.WriteByte('\n')
+	b.WriteString(payloadHash)
+	return b.String(), signedHeaders
+}
+
+func canonicalV4Path(u *url.URL, service string) string {
+	p := u.EscapedPath()
+	if p == "" {
+		return "/"
+	}
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		if s, err := url.PathUnescape(seg); err == nil {
+			seg = s
+		}
+		seg = awsEscape(seg)
+		if service != "s3" {
+			seg = awsEscape(seg)
+		}
+		segments[i] = seg
+	}
+	return strings.Join(segments, "/")
+}
+
+func canonicalV4Query(u *url.URL) string {
+	q := u.Query()
+	pairs := make([]string, 0, len(q))
+	for k, vs := range q {
+		for _, v := range vs {
+			pairs = append(pairs, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+func awsEscape(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hexDigits[c>>4])
+		b.WriteByte(hexDigits[c&15])
+	}
+	return b.String()
+}
+
+func sigV4SigningKey(secret, date, region, service string) []byte {
+	k := hmacSHA256([]byte("AWS4"+secret), []byte(date))
+	k = hmacSHA256(k, []byte(region))
+	k = hmacSHA256(k, []byte(service))
+	return hmacSHA256(k, []byte("aws4_request"))
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+type chunkSigner struct {
+	key       []byte
+	amzDate   string
+	scope     string
+	seed      string
+	chunkSize int
+}
+
+func (s *chunkSigner) encodedLength(n int64) int64 {
+	chunkLength := func(size int64) int64 {
+		return int64(len(strconv.FormatInt(size, 16))+len(sigV4ChunkSignature)+64+2) + size + 2
+	}
+	size := int64(s.chunkSize)
+	total := (n / size) * chunkLength(size)
+	if rem := n % size; rem > 0 {
+		total += chunkLength(rem)
+	}
+	return total + chunkLength(0)
+}
+
+func (s *chunkSigner) newBody(body io.Reader) io.ReadCloser {
+	return newStreamBody(func(w io.Writer) error {
+		if c, ok := body.(io.Closer); ok {
+			defer c.Close()
+		}
+		prev := s.seed
+		buf := make([]byte, s.chunkSize)
+		for {
+			n, err := io.ReadFull(body, buf)
+			if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+				return err
+			}
+			if n > 0 {
+				if prev, err = s.writeChunk(w, prev, buf[:n]); err != nil {
+					return err
+				}
+			}
+			if err != nil {
+				break
+			}
+		}
+		_, err := s.writeChunk(w, prev, nil)
+		return err
+	})
+}
+
+func (s *chunkSigner) writeChunk(w io.Writer, prev string, data []byte) (string, error) {
+	stringToSign := strings.Join([]string{sigV4ChunkAlgorithm, s.amzDate, s.scope, prev, emptySHA256, hexSHA256(data)}, "\n")
+	signature := hex.EncodeToString(hmacSHA256(s.key, []byte(stringToSign)))
+	var b bytes.Buffer
+	b.WriteString(strconv.FormatInt(int64(len(data)), 16))
+	b.WriteString(sigV4ChunkSignature)
+	b.WriteString(signature)
+	b.WriteString("\r\n")
+	b.Write(data)
+	b.WriteString("\r\n")
+	_, err := io.Copy(w, &b)
+	return signature, err
+}